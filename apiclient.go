@@ -0,0 +1,180 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// apiClient sends authenticated requests to the API server of a context.
+type apiClient struct {
+	server   string
+	client   *http.Client
+	token    string
+	username string
+	password string
+}
+
+// decodeData returns the base64 decoded value of a *-data setting.
+func decodeData(extra map[string]interface{}, key string) ([]byte, error) {
+	s, _ := extra[key].(string)
+	if len(s) < 1 {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("[API] invalid %s: %v", key, err)
+	}
+	return data, nil
+}
+
+// execCredential runs the credential plugin of the user and returns the token or the client
+// certificate it prints.
+func execCredential(ctx context.Context, e *ExecConfig) (string, []byte, []byte, error) {
+	apiVersion := e.APIVersion
+	if len(apiVersion) < 1 {
+		apiVersion = defaultExecAPIVersion
+	}
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf(
+		`KUBERNETES_EXEC_INFO={"apiVersion":%q,"kind":"ExecCredential","spec":{"interactive":false}}`, apiVersion,
+	))
+	for _, env := range e.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("[API] credential plugin %s failed: %v %s", e.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	cred := struct {
+		Status struct {
+			Token                 string `json:"token"`
+			ClientCertificateData string `json:"clientCertificateData"`
+			ClientKeyData         string `json:"clientKeyData"`
+		} `json:"status"`
+	}{}
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return "", nil, nil, fmt.Errorf("[API] invalid output of the credential plugin %s: %v", e.Command, err)
+	}
+	s := cred.Status
+	return s.Token, []byte(s.ClientCertificateData), []byte(s.ClientKeyData), nil
+}
+
+// newAPIClient resolves the server, certificate authority and credentials of the context.
+// Referenced files are read via Extract.
+func (k *KubeConfig) newAPIClient(ctx context.Context, contextName string) (*apiClient, error) {
+	extracted, err := k.Extract(contextName)
+	if err != nil {
+		return nil, err
+	}
+	cluster, user := extracted.Clusters[0].Cluster, extracted.Users[0].User
+	if len(cluster.Server) < 1 {
+		return nil, fmt.Errorf("[API] no server configured for the cluster '%s'", extracted.Clusters[0].Name)
+	}
+	p := &apiClient{server: strings.TrimRight(cluster.Server, "/")}
+	tlsConfig := &tls.Config{}
+	if len(cluster.CertificateAuthorityData) > 0 {
+		ca, err := base64.StdEncoding.DecodeString(cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("[API] invalid certificate-authority-data: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("[API] no certificate found in the certificate-authority-data")
+		}
+	}
+	if skip, _ := cluster.Extra["insecure-skip-tls-verify"].(bool); skip {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if name, _ := cluster.Extra["tls-server-name"].(string); len(name) > 0 {
+		tlsConfig.ServerName = name
+	}
+	var cert, key []byte
+	if cert, err = decodeData(user.Extra, "client-certificate-data"); err != nil {
+		return nil, err
+	}
+	if key, err = decodeData(user.Extra, "client-key-data"); err != nil {
+		return nil, err
+	}
+	p.token, _ = user.Extra["token"].(string)
+	if path, _ := user.Extra["tokenFile"].(string); len(path) > 0 && len(p.token) < 1 {
+		data, err := k.fs().ReadFile(k.resolvePath(path))
+		if err != nil {
+			return nil, fmt.Errorf("[API] unable to read the tokenFile: %v", err)
+		}
+		p.token = strings.TrimSpace(string(data))
+	}
+	p.username, _ = user.Extra["username"].(string)
+	p.password, _ = user.Extra["password"].(string)
+	if user.Exec != nil {
+		token, execCert, execKey, err := execCredential(ctx, user.Exec)
+		if err != nil {
+			return nil, err
+		}
+		if len(token) > 0 {
+			p.token = token
+		}
+		if len(execCert) > 0 {
+			cert, key = execCert, execKey
+		}
+	}
+	if len(cert) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("[API] invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	p.client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+	return p, nil
+}
+
+// do sends the request with the credentials and returns the status code and the body.
+func (p *apiClient) do(ctx context.Context, method, path, body string) (int, []byte, error) {
+	req, err := http.NewRequest(method, p.server+path, strings.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(p.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	} else if len(p.username) > 0 {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
@@ -0,0 +1,210 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionSetContext   = "set-context"
+	ActionUnsetContext = "unset-context"
+	ActionSetProfile   = "set-profile"
+	ActionSetNamespace = "set-namespace"
+	ActionSetCluster   = "set-cluster"
+	ActionSetUser      = "set-user"
+	ActionAddContext   = "add-context"
+	ActionRename       = "rename-context"
+	ActionProtect      = "protect"
+	ActionAddCluster   = "add-cluster"
+	ActionRmCluster    = "remove-cluster"
+	ActionSetServer    = "set-server"
+	ActionSetCA        = "set-ca"
+	ActionAddUser      = "add-user"
+	ActionRmUser       = "remove-user"
+	ActionSetExec      = "set-exec"
+	ActionMerge        = "merge"
+	ActionPrune        = "prune"
+	ActionLabel        = "label"
+)
+
+type (
+	// AuditRecord describes a single change of the kube config or the AWS credentials file.
+	// Old and New hold the changed value: the current-context, the aws-profile, the namespace
+	// or the name of an added context depending on the action.
+	AuditRecord struct {
+		Time    time.Time `json:"time"`
+		User    string    `json:"user"`
+		Host    string    `json:"host"`
+		Action  string    `json:"action"`
+		Context string    `json:"context,omitempty"`
+		// Cluster and KubeUser name the changed entry of the cluster and user actions.
+		Cluster  string `json:"cluster,omitempty"`
+		KubeUser string `json:"kube-user,omitempty"`
+		Old      string `json:"old"`
+		New      string `json:"new"`
+		// OldProfile and Profile are the AWS profiles used before and after the change.
+		OldProfile string `json:"old-profile,omitempty"`
+		Profile    string `json:"profile,omitempty"`
+		Account    string `json:"account,omitempty"`
+		Caller     string `json:"caller"`
+	}
+
+	// AuditSink receives the audit records.
+	AuditSink interface {
+		Write(r AuditRecord) error
+	}
+
+	// FileSink appends the records as JSON lines to a local file. Without FS the records are
+	// written to the disk.
+	FileSink struct {
+		Path string
+		FS   FileSystem
+	}
+
+	// WebhookSink posts every record as JSON to an URL.
+	WebhookSink struct {
+		URL    string
+		Client *http.Client
+	}
+)
+
+// Write appends the record to the file.
+func (s *FileSink) Write(r AuditRecord) error {
+	line, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	fs := s.FS
+	if fs == nil {
+		fs = OSFileSystem{}
+	}
+	if err := fs.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	return fs.AppendFile(s.Path, append(line, '\n'), 0600)
+}
+
+// Write posts the record to the URL and expects a 2xx status code.
+func (s *WebhookSink) Write(r AuditRecord) error {
+	body, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook '%s' responded with '%s'", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// AuditSinkFrom returns the sink for the given specification, which is either
+// 'syslog[:<tag>]', a http(s) URL for a webhook or the path of a local file, optionally
+// prefixed with 'file:'.
+func AuditSinkFrom(spec string) (AuditSink, error) {
+	switch {
+	case len(spec) < 1:
+		return nil, fmt.Errorf("[AUDIT] empty sink")
+	case spec == "syslog" || strings.HasPrefix(spec, "syslog:"):
+		tag := strings.TrimPrefix(strings.TrimPrefix(spec, "syslog"), ":")
+		if len(tag) < 1 {
+			tag = "eksdefault"
+		}
+		return newSyslogSink(tag)
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		return &WebhookSink{URL: spec}, nil
+	}
+	return &FileSink{Path: strings.TrimPrefix(strings.TrimPrefix(spec, "file://"), "file:")}, nil
+}
+
+// AuditSpec returns the sink configured via the environment variable EKSDEFAULT_AUDIT or an
+// empty string, if auditing is disabled.
+func AuditSpec() string {
+	return os.Getenv("EKSDEFAULT_AUDIT")
+}
+
+// audit completes the record with the user, host, time and caller and writes it to the
+// configured sink. Without sink or in DryRun mode nothing is recorded. The record is written
+// after the change, so an error reports a change, which is applied, but not recorded.
+func (k *KubeConfig) audit(r AuditRecord) error {
+	if k.DryRun {
+		return nil
+	}
+	sink := k.Audit
+	if sink == nil {
+		if k.auditSink == nil {
+			spec := k.setting("audit")
+			if len(spec) < 1 {
+				return nil
+			}
+			s, err := AuditSinkFrom(spec)
+			if err != nil {
+				return err
+			}
+			if f, ok := s.(*FileSink); ok {
+				f.FS = k.fs()
+			}
+			k.auditSink = s
+		}
+		sink = k.auditSink
+	}
+	r.Time = time.Now().UTC()
+	r.User = currentUser()
+	r.Host, _ = os.Hostname()
+	r.Caller = k.Caller
+	if err := sink.Write(r); err != nil {
+		return fmt.Errorf("[AUDIT] the change was applied, but could not be recorded: %v", err)
+	}
+	return nil
+}
+
+// Close releases the audit sink configured via EKSDEFAULT_AUDIT or the config file, like the
+// connection to syslog. A sink set as Audit is left to the caller.
+func (k *KubeConfig) Close() error {
+	c, ok := k.auditSink.(io.Closer)
+	k.auditSink = nil
+	if !ok {
+		return nil
+	}
+	return c.Close()
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); len(name) > 0 {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,50 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package eksdefault
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink sends the records as JSON to the local syslog daemon.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink(tag string) (AuditSink, error) {
+	w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+// Write sends the record to syslog.
+func (s *SyslogSink) Write(r AuditRecord) error {
+	line, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	return s.w.Notice(string(line))
+}
+
+// Close closes the connection to syslog.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
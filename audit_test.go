@@ -0,0 +1,193 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAuditSinkFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    AuditSink
+		wantErr bool
+	}{
+		{
+			name: "0positive - plain path",
+			spec: "/var/log/eksdefault.log",
+			want: &FileSink{Path: "/var/log/eksdefault.log"},
+		},
+		{
+			name: "1positive - file URL",
+			spec: "file:///var/log/eksdefault.log",
+			want: &FileSink{Path: "/var/log/eksdefault.log"},
+		},
+		{
+			name: "2positive - webhook",
+			spec: "https://audit.example.com/hook",
+			want: &WebhookSink{URL: "https://audit.example.com/hook"},
+		},
+		{
+			name:    "3negative - empty",
+			spec:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AuditSinkFrom(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuditSinkFrom() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("AuditSinkFrom() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestAudit_file(t *testing.T) {
+	path, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	log := filepath.Join(filepath.Dir(path), "audit", "log")
+	t.Setenv("EKSDEFAULT_AUDIT", log)
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Caller = CallerCLI
+	if err := k.SetContextTo("cntxC"); err != nil {
+		t.Fatal(err)
+	}
+	sink := k.auditSink
+	if err := k.AddNamespaceTo("cntxC", "kube-system"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddProfileTo("cntxC", "live"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddContext(KubeContext{Name: "new", AWSprofile: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddContext(KubeContext{Name: "new"}); err == nil {
+		t.Errorf("AddContext() with a duplicated name, want error")
+	}
+	if err := k.UnSetDefault(); err != nil {
+		t.Fatal(err)
+	}
+	if sink == nil || k.auditSink != sink {
+		t.Errorf("audit sink = %v, want the first sink %v kept", k.auditSink, sink)
+	}
+	if err := k.Close(); err != nil || k.auditSink != nil {
+		t.Errorf("Close() = %v, want the sink released", err)
+	}
+
+	f, err := os.Open(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got := []AuditRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	want := []AuditRecord{
+		{Action: ActionSetContext, Context: "cntxC", Old: "cntxB", New: "cntxC", Profile: "dev"},
+		{Action: ActionSetNamespace, Context: "cntxC", Old: "ccccc", New: "kube-system", Profile: "dev"},
+		{Action: ActionSetProfile, Context: "cntxC", Old: "dev", New: "live", Profile: "live"},
+		{Action: ActionAddContext, Context: "new", New: "new", Profile: "dev"},
+		{Action: ActionUnsetContext, Context: "cntxC", Old: "cntxC"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("audit log contains %d records, want %d", len(got), len(want))
+	}
+	for i, r := range got {
+		if r.Time.IsZero() || len(r.User) < 1 || len(r.Host) < 1 || r.Caller != CallerCLI {
+			t.Errorf("record %d = %+v, want time, user, host and caller", i, r)
+		}
+		w := want[i]
+		if r.Action != w.Action || r.Context != w.Context || r.Old != w.Old || r.New != w.New || r.Profile != w.Profile {
+			t.Errorf("record %d = %+v, want %+v", i, r, w)
+		}
+	}
+}
+
+func TestAudit_webhook(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+
+	var (
+		mu      sync.Mutex
+		records []AuditRecord
+		status  = http.StatusNoContent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		rec := AuditRecord{}
+		if r.Method != http.MethodPost || json.Unmarshal(body, &rec) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, rec)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Audit, err = AuditSinkFrom(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Contexts[0].Context.Cluster = "arn:aws:eks:eu-west-1:123456789012:cluster/a"
+	if err := k.SetContextTo("cntxA"); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].New != "cntxA" || records[0].Account != "123456789012" {
+		t.Errorf("webhook received %+v, want the switch to cntxA in account 123456789012", records)
+	}
+	mu.Lock()
+	status = http.StatusInternalServerError
+	mu.Unlock()
+	if err := k.SetProtected("cntxA", true); err == nil || !strings.Contains(err.Error(), "was applied") {
+		t.Errorf("SetProtected() with failing webhook error = %v, want the change reported as applied", err)
+	}
+}
@@ -0,0 +1,222 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-ini/ini"
+	"github.com/peterbueschel/awsdefault"
+)
+
+// Change is the content of a file before and after a change. Changes are collected instead
+// of written, if the KubeConfig is in DryRun mode.
+type Change struct {
+	Path string
+	Old  []byte
+	New  []byte
+}
+
+// Diff returns the change as unified diff with three lines of context.
+func (c Change) Diff() string {
+	return UnifiedDiff(c.Path, c.Old, c.New)
+}
+
+// writeFile writes the content to the file or, in DryRun mode, records it as Change. Several
+// changes of the same file are merged into one.
+func (k *KubeConfig) writeFile(path string, content []byte, perm os.FileMode) error {
+	if !k.DryRun {
+		return k.fs().WriteFile(path, content, perm)
+	}
+	for idx := range k.Changes {
+		if k.Changes[idx].Path == path {
+			k.Changes[idx].New = content
+			return nil
+		}
+	}
+	old, err := k.fs().ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	k.Changes = append(k.Changes, Change{Path: path, Old: old, New: content})
+	return nil
+}
+
+// credentialsContent returns the content of the AWS credentials file with the given profile
+// as default profile. Without profile name the default section is removed.
+func credentialsContent(awsfile *awsdefault.CredentialsFile, profileName string) ([]byte, error) {
+	if len(profileName) < 1 {
+		awsfile.Content.DeleteSection("default")
+	} else {
+		p, err := awsfile.GetProfileBy(profileName)
+		if err != nil {
+			return nil, err
+		}
+		_ = awsfile.Content.Section("default").ReflectFrom(p) // error cannot happen; p is always a pointer
+	}
+	var b bytes.Buffer
+	// the case insensitive [default] profile is the default section of go-ini, whose header
+	// is only written with the package wide ini.DefaultHeader
+	if !ini.DefaultHeader && len(awsfile.Content.Sections()[0].Keys()) > 0 {
+		b.WriteString("[default]" + ini.LineBreak)
+	}
+	if _, err := awsfile.Content.WriteTo(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// setDefaultProfile changes the default profile inside the AWS credentials file like
+// awsdefault.SetDefaultTo, but respects the DryRun mode.
+func (k *KubeConfig) setDefaultProfile(awsfile *awsdefault.CredentialsFile, profileName string) error {
+	content, err := credentialsContent(awsfile, profileName)
+	if err != nil {
+		return err
+	}
+	return k.writeFile(awsfile.Path, content, 0600)
+}
+
+// UnifiedDiff compares the old and the new content line by line and returns the differences
+// in the unified format. An empty string is returned for equal contents.
+func UnifiedDiff(path string, old, new []byte) string {
+	const context = 3
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// collect the hunk until the changes are more than twice the context apart
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end, unchanged := i, 0
+		for ; end < len(ops) && unchanged <= 2*context; end++ {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		end -= unchanged - context
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if out.Len() < 1 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+		}
+		aStart, bStart, aLen, bLen := ops[start].a, ops[start].b, 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func splitLines(content []byte) []string {
+	s := strings.TrimSuffix(string(content), "\n")
+	if len(s) < 1 {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffOp is a single line of the edit script; kind is ' ', '-' or '+'. a and b are the
+// positions inside the old and the new lines.
+type diffOp struct {
+	kind rune
+	line string
+	a, b int
+}
+
+// diffLines returns the edit script from a to b based on the longest common subsequence.
+// Common prefixes and suffixes are skipped first, which keeps the table small for the usual
+// small changes of large files.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := 0; i < pre; i++ {
+		ops = append(ops, diffOp{' ', a[i], i, i})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i], pre + i, pre + j})
+			i++
+			j++
+		case j < len(mb) && (i == len(ma) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', mb[j], pre + i, pre + j})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', ma[i], pre + i, pre + j})
+			i++
+		}
+	}
+	for k := 0; k < suf; k++ {
+		ops = append(ops, diffOp{' ', a[len(a)-suf+k], len(a) - suf + k, len(b) - suf + k})
+	}
+	return ops
+}
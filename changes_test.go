@@ -0,0 +1,164 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/go-ini/ini"
+	"github.com/peterbueschel/awsdefault"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "0positive - equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "1positive - changed line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want: "--- f\n+++ f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "2positive - two hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want: "--- f\n+++ f\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name: "3positive - new file",
+			old:  "",
+			new:  "x\ny\n",
+			want: "--- f\n+++ f\n@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			name: "4positive - insertion",
+			old:  "a\nc\n",
+			new:  "a\nb\nc\n",
+			want: "--- f\n+++ f\n@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("f", []byte(tt.old), []byte(tt.new)); got != tt.want {
+				t.Errorf("UnifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubeConfig_DryRun(t *testing.T) {
+	path, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	awsfile, err := awsdefault.GetCredentialsFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	credsBefore, err := ioutil.ReadFile(awsfile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := ReadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.DryRun = true
+	if err := k.SetContextTo("cntxC"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddNamespaceTo("cntxC", "kube-system"); err != nil {
+		t.Fatal(err)
+	}
+	if len(k.Changes) != 2 {
+		t.Fatalf("DryRun recorded %d changes, want 2", len(k.Changes))
+	}
+	kube, creds := "", ""
+	for _, c := range k.Changes {
+		switch c.Path {
+		case path:
+			kube = c.Diff()
+		case awsfile.Path:
+			creds = c.Diff()
+		}
+	}
+	for _, want := range []string{"-current-context: cntxB\n", "+current-context: cntxC\n", "-    namespace: ccccc\n", "+    namespace: kube-system\n"} {
+		if !strings.Contains(kube, want) {
+			t.Errorf("kube config diff = %s, want it to contain %q", kube, want)
+		}
+	}
+	if !strings.HasPrefix(creds, "--- "+awsfile.Path) || !strings.Contains(creds, "+aws_access_key_id") {
+		t.Errorf("credentials diff = %s, want the new default section", creds)
+	}
+	// nothing was written
+	if after, _ := ioutil.ReadFile(path); string(after) != string(before) {
+		t.Errorf("DryRun changed the kube config")
+	}
+	if after, _ := ioutil.ReadFile(awsfile.Path); string(after) != string(credsBefore) {
+		t.Errorf("DryRun changed the credentials file")
+	}
+	if entries, _ := ReadHistory(); len(entries) != len(history) {
+		t.Errorf("DryRun recorded the switch in the history")
+	}
+}
+
+func Test_credentialsContent(t *testing.T) {
+	defaultHeader := ini.DefaultHeader
+	ini.DefaultHeader = false
+	defer func() { ini.DefaultHeader = defaultHeader }()
+
+	fs := memFiles(t, true)
+	k := &KubeConfig{Path: memKubeConfigPath, CredentialsPath: memCredentialsPath, FS: fs}
+	awsfile, err := k.credentialsFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := credentialsContent(awsfile, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "[default]\n") {
+		t.Errorf("credentialsContent() = %s, want the [default] profile first", content)
+	}
+	if err := fs.WriteFile(memCredentialsPath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if awsfile, err = k.credentialsFile(); err != nil {
+		t.Fatal(err)
+	}
+	if p := activeProfile(awsfile); p != "dev" {
+		t.Errorf("default profile = %s, want dev", p)
+	}
+}
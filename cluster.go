@@ -0,0 +1,263 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// defaultExecAPIVersion is the version of the ExecCredential used by credential plugins.
+const defaultExecAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+type (
+	// KubeCluster is an entry of the clusters list inside the kube config.
+	KubeCluster struct {
+		Name    string       `yaml:"name"`
+		Cluster *ClusterInfo `yaml:"cluster"`
+	}
+	// ClusterInfo contains the connection settings of a cluster. Settings unknown to
+	// eksdefault are kept in Extra.
+	ClusterInfo struct {
+		CertificateAuthority     string                 `yaml:"certificate-authority,omitempty"`
+		CertificateAuthorityData string                 `yaml:"certificate-authority-data,omitempty"`
+		Server                   string                 `yaml:"server,omitempty"`
+		Extra                    map[string]interface{} `yaml:",inline"`
+	}
+
+	// KubeUser is an entry of the users list inside the kube config.
+	KubeUser struct {
+		Name string    `yaml:"name"`
+		User *UserInfo `yaml:"user"`
+	}
+	// UserInfo contains the credentials of a user. Settings unknown to eksdefault, like
+	// tokens or client certificates, are kept in Extra.
+	UserInfo struct {
+		Exec  *ExecConfig            `yaml:"exec,omitempty"`
+		Extra map[string]interface{} `yaml:",inline"`
+	}
+	// ExecConfig is the credential plugin, which returns the token; usually 'aws eks get-token'
+	// or 'aws-iam-authenticator'.
+	ExecConfig struct {
+		APIVersion string                 `yaml:"apiVersion,omitempty"`
+		Args       []string               `yaml:"args,omitempty"`
+		Command    string                 `yaml:"command"`
+		Env        []ExecEnv              `yaml:"env,omitempty"`
+		Extra      map[string]interface{} `yaml:",inline"`
+	}
+	// ExecEnv is an environment variable passed to the credential plugin.
+	ExecEnv struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	}
+)
+
+// GetClusterBy returns the cluster entry with the given name.
+func (k *KubeConfig) GetClusterBy(name string) (*KubeCluster, int, error) {
+	for idx, c := range k.Clusters {
+		if c.Name == name {
+			if c.Cluster == nil {
+				c.Cluster = &ClusterInfo{}
+			}
+			return &c, idx, nil
+		}
+	}
+	return &KubeCluster{}, -1, fmt.Errorf("[GETCLUSTER] cannot find cluster named '%s'", name)
+}
+
+// GetUserBy returns the user entry with the given name.
+func (k *KubeConfig) GetUserBy(name string) (*KubeUser, int, error) {
+	for idx, u := range k.Users {
+		if u.Name == name {
+			if u.User == nil {
+				u.User = &UserInfo{}
+			}
+			return &u, idx, nil
+		}
+	}
+	return &KubeUser{}, -1, fmt.Errorf("[GETUSER] cannot find user named '%s'", name)
+}
+
+// ContextsUsing returns the names of the contexts referencing the cluster or the user. One
+// of both can be empty.
+func (k *KubeConfig) ContextsUsing(cluster, user string) (names []string) {
+	for _, c := range k.Contexts {
+		if c.Context == nil {
+			continue
+		}
+		if len(cluster) > 0 && c.Context.Cluster == cluster || len(user) > 0 && c.Context.User == user {
+			names = append(names, c.Name)
+		}
+	}
+	return
+}
+
+// AddCluster appends the new cluster to the kube config.
+func (k *KubeConfig) AddCluster(c KubeCluster) error {
+	if _, _, err := k.GetClusterBy(c.Name); err == nil {
+		return fmt.Errorf("[ADDCLUSTER] a cluster named '%s' already exists", c.Name)
+	}
+	if c.Cluster == nil {
+		c.Cluster = &ClusterInfo{}
+	}
+	k.Clusters = append(k.Clusters, c)
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{Action: ActionAddCluster, Cluster: c.Name, New: c.Cluster.Server})
+}
+
+// RemoveCluster deletes the cluster from the kube config. A cluster still referenced by a
+// context is only removed, if force is set.
+func (k *KubeConfig) RemoveCluster(name string, force bool) error {
+	c, idx, err := k.GetClusterBy(name)
+	if err != nil {
+		return err
+	}
+	if used := k.ContextsUsing(name, ""); len(used) > 0 && !force {
+		return fmt.Errorf("[RMCLUSTER] the cluster '%s' is used by the contexts %s", name, strings.Join(used, ", "))
+	}
+	k.Clusters = append(k.Clusters[:idx], k.Clusters[idx+1:]...)
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{Action: ActionRmCluster, Cluster: name, Old: c.Cluster.Server})
+}
+
+// SetClusterServer changes the URL of the API server of the cluster.
+func (k *KubeConfig) SetClusterServer(name, server string) error {
+	c, idx, err := k.GetClusterBy(name)
+	if err != nil {
+		return err
+	}
+	old := c.Cluster.Server
+	info := *c.Cluster
+	info.Server = server
+	k.Clusters[idx].Cluster = &info
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{Action: ActionSetServer, Cluster: name, Old: old, New: server})
+}
+
+// SetClusterCA configures the PEM encoded certificate authority of the cluster. With embed
+// the certificate is stored as certificate-authority-data inside the kube config; otherwise
+// the absolute path of the file is referenced.
+func (k *KubeConfig) SetClusterCA(name, path string, embed bool) error {
+	c, idx, err := k.GetClusterBy(name)
+	if err != nil {
+		return err
+	}
+	info := *c.Cluster
+	if err := k.ReadClusterCA(&info, path, embed); err != nil {
+		return err
+	}
+	k.Clusters[idx].Cluster = &info
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	if len(info.CertificateAuthority) > 0 {
+		path = info.CertificateAuthority
+	}
+	return k.audit(AuditRecord{Action: ActionSetCA, Cluster: name, New: path})
+}
+
+// ReadClusterCA checks, that the file contains a PEM encoded certificate and configures it
+// as certificate authority of info like SetClusterCA, but without saving anything.
+func (k *KubeConfig) ReadClusterCA(info *ClusterInfo, path string, embed bool) error {
+	data, err := k.fs().ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if block, _ := pem.Decode(data); block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("[SETCA] '%s' does not contain a PEM encoded certificate", path)
+	}
+	if embed {
+		info.CertificateAuthority = ""
+		info.CertificateAuthorityData = base64.StdEncoding.EncodeToString(data)
+		return nil
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+	info.CertificateAuthority = path
+	info.CertificateAuthorityData = ""
+	return nil
+}
+
+// AddUser appends the new user to the kube config.
+func (k *KubeConfig) AddUser(u KubeUser) error {
+	if _, _, err := k.GetUserBy(u.Name); err == nil {
+		return fmt.Errorf("[ADDUSER] a user named '%s' already exists", u.Name)
+	}
+	if u.User == nil {
+		u.User = &UserInfo{}
+	}
+	k.Users = append(k.Users, u)
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{Action: ActionAddUser, KubeUser: u.Name})
+}
+
+// RemoveUser deletes the user from the kube config. A user still referenced by a context is
+// only removed, if force is set.
+func (k *KubeConfig) RemoveUser(name string, force bool) error {
+	_, idx, err := k.GetUserBy(name)
+	if err != nil {
+		return err
+	}
+	if used := k.ContextsUsing("", name); len(used) > 0 && !force {
+		return fmt.Errorf("[RMUSER] the user '%s' is used by the contexts %s", name, strings.Join(used, ", "))
+	}
+	k.Users = append(k.Users[:idx], k.Users[idx+1:]...)
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{Action: ActionRmUser, KubeUser: name})
+}
+
+// SetUserExec replaces the credential plugin of the user.
+func (k *KubeConfig) SetUserExec(name string, exec ExecConfig) error {
+	u, idx, err := k.GetUserBy(name)
+	if err != nil {
+		return err
+	}
+	if len(exec.Command) < 1 {
+		return fmt.Errorf("[SETEXEC] the command of the credential plugin is required")
+	}
+	if len(exec.APIVersion) < 1 {
+		exec.APIVersion = defaultExecAPIVersion
+	}
+	old := ""
+	if u.User.Exec != nil {
+		old = strings.TrimSpace(strings.Join(append([]string{u.User.Exec.Command}, u.User.Exec.Args...), " "))
+	}
+	info := *u.User
+	info.Exec = &exec
+	k.Users[idx].User = &info
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{
+		Action:   ActionSetExec,
+		KubeUser: name,
+		Old:      old,
+		New:      strings.Join(append([]string{exec.Command}, exec.Args...), " "),
+	})
+}
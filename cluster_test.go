@@ -0,0 +1,198 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const eksKubeConfig = `apiVersion: v1
+kind: Config
+preferences: {}
+clusters:
+- cluster:
+    certificate-authority-data: Q0E=
+    server: https://ABC.gr7.eu-west-1.eks.amazonaws.com
+    tls-server-name: abc
+  name: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+- cluster:
+    server: https://127.0.0.1:6443
+  name: kind
+contexts:
+- context:
+    cluster: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+    user: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+  name: prod
+  aws-profile: live
+current-context: prod
+users:
+- name: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      args:
+      - eks
+      - get-token
+      - --cluster-name
+      - prod
+      command: aws
+      interactiveMode: IfAvailable
+- name: kind
+  user:
+    token: secret
+`
+
+// setupEKSConfig writes a kube config like created by 'aws eks update-kubeconfig'.
+func setupEKSConfig(t *testing.T) (string, func()) {
+	path, teardown := setupTempFiles(t, 0)
+	if err := ioutil.WriteFile(path, []byte(eksKubeConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, teardown
+}
+
+func TestKubeConfig_clusters(t *testing.T) {
+	path, teardown := setupEKSConfig(t)
+	defer teardown()
+	prod := "arn:aws:eks:eu-west-1:123456789012:cluster/prod"
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddCluster(KubeCluster{Name: "new", Cluster: &ClusterInfo{Server: "https://new"}}); err != nil {
+		t.Errorf("AddCluster() error = %v", err)
+	}
+	if err := k.AddCluster(KubeCluster{Name: "kind"}); err == nil {
+		t.Errorf("AddCluster() of an existing cluster, want error")
+	}
+	if err := k.SetClusterServer("kind", "https://127.0.0.1:7443"); err != nil {
+		t.Errorf("SetClusterServer() error = %v", err)
+	}
+	ca := filepath.Join(filepath.Dir(path), "ca.crt")
+	if err := ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("ca")}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetClusterCA("new", ca, true); err != nil {
+		t.Errorf("SetClusterCA() error = %v", err)
+	}
+	if err := k.SetClusterCA("kind", ca, false); err != nil {
+		t.Errorf("SetClusterCA() error = %v", err)
+	}
+	if err := k.SetClusterCA("kind", path, false); err == nil {
+		t.Errorf("SetClusterCA() with a file without certificate, want error")
+	}
+	if err := k.RemoveCluster(prod, false); err == nil || !strings.Contains(err.Error(), "prod") {
+		t.Errorf("RemoveCluster() of a used cluster error = %v, want the using contexts", err)
+	}
+	if err := k.RemoveCluster("unknown", true); err == nil {
+		t.Errorf("RemoveCluster() of an unknown cluster, want error")
+	}
+
+	k, _ = GetConfigFile()
+	if got := strings.Join(k.ClusterNames(), ","); got != prod+",kind,new" {
+		t.Errorf("ClusterNames() = %s", got)
+	}
+	c, _, _ := k.GetClusterBy("new")
+	if want := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("ca")})); c.Cluster.CertificateAuthorityData != want {
+		t.Errorf("certificate-authority-data = %s, want %s", c.Cluster.CertificateAuthorityData, want)
+	}
+	c, _, _ = k.GetClusterBy("kind")
+	if c.Cluster.Server != "https://127.0.0.1:7443" || c.Cluster.CertificateAuthority != ca {
+		t.Errorf("cluster kind = %+v", c.Cluster)
+	}
+	// unknown settings are kept
+	c, _, _ = k.GetClusterBy(prod)
+	if c.Cluster.Extra["tls-server-name"] != "abc" || c.Cluster.CertificateAuthorityData != "Q0E=" {
+		t.Errorf("cluster prod = %+v", c.Cluster)
+	}
+	if err := k.RemoveCluster(prod, true); err != nil {
+		t.Errorf("RemoveCluster() forced error = %v", err)
+	}
+	if k.IsDangling(k.Contexts[0]) != true {
+		t.Errorf("context prod references the removed cluster, want dangling")
+	}
+}
+
+func TestKubeConfig_users(t *testing.T) {
+	_, teardown := setupEKSConfig(t)
+	defer teardown()
+	prod := "arn:aws:eks:eu-west-1:123456789012:cluster/prod"
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddUser(KubeUser{Name: "new"}); err != nil {
+		t.Errorf("AddUser() error = %v", err)
+	}
+	if err := k.AddUser(KubeUser{Name: "kind"}); err == nil {
+		t.Errorf("AddUser() of an existing user, want error")
+	}
+	exec := ExecConfig{Command: "aws", Args: []string{"eks", "get-token", "--cluster-name", "new"}}
+	if err := k.SetUserExec("new", exec); err != nil {
+		t.Errorf("SetUserExec() error = %v", err)
+	}
+	if err := k.SetUserExec("new", ExecConfig{}); err == nil {
+		t.Errorf("SetUserExec() without command, want error")
+	}
+	if err := k.RemoveUser(prod, false); err == nil {
+		t.Errorf("RemoveUser() of a used user, want error")
+	}
+	if err := k.RemoveUser("kind", false); err != nil {
+		t.Errorf("RemoveUser() error = %v", err)
+	}
+
+	k, _ = GetConfigFile()
+	if got := strings.Join(k.UserNames(), ","); got != prod+",new" {
+		t.Errorf("UserNames() = %s", got)
+	}
+	u, _, _ := k.GetUserBy("new")
+	if u.User.Exec == nil || u.User.Exec.Command != "aws" || u.User.Exec.APIVersion != "client.authentication.k8s.io/v1beta1" {
+		t.Errorf("user new = %+v", u.User.Exec)
+	}
+	// unknown settings are kept
+	u, _, _ = k.GetUserBy(prod)
+	if u.User.Exec.Extra["interactiveMode"] != "IfAvailable" || len(u.User.Exec.Args) != 4 {
+		t.Errorf("user prod = %+v", u.User.Exec)
+	}
+}
+
+func TestKubeConfig_roundTrip(t *testing.T) {
+	path, teardown := setupEKSConfig(t)
+	defer teardown()
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SaveContexts(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"tls-server-name: abc", "interactiveMode: IfAvailable", "token: secret", "- --cluster-name"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("saved kube config lost %q:\n%s", want, got)
+		}
+	}
+}
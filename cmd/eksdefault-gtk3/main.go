@@ -71,6 +71,57 @@ func (c *chooser) selectionChanged() error {
 	return nil
 }
 
+// confirmProtected asks via dialog to type in the name of the protected context before
+// switching to it.
+func confirmProtected(ctx *eksdefault.KubeContext, account string) error {
+	d, err := gtk.DialogNew()
+	if err != nil {
+		return err
+	}
+	defer d.Destroy()
+	d.SetTitle(appName)
+	d.SetKeepAbove(true)
+	d.SetPosition(gtk.WIN_POS_MOUSE)
+	if _, err = d.AddButton("Cancel", gtk.RESPONSE_CANCEL); err != nil {
+		return err
+	}
+	if _, err = d.AddButton("Switch", gtk.RESPONSE_OK); err != nil {
+		return err
+	}
+	d.SetDefaultResponse(gtk.RESPONSE_OK)
+	area, err := d.GetContentArea()
+	if err != nil {
+		return err
+	}
+	if len(account) < 1 {
+		account = "unknown"
+	}
+	label, err := gtk.LabelNew(fmt.Sprintf(
+		"The context '%s' is protected.\naws profile: %s\naccount: %s\ncluster: %s\n\nType the name of the context to confirm:",
+		ctx.Name, ctx.AWSprofile, account, ctx.Context.Cluster,
+	))
+	if err != nil {
+		return err
+	}
+	entry, err := gtk.EntryNew()
+	if err != nil {
+		return err
+	}
+	entry.SetActivatesDefault(true)
+	area.PackStart(label, false, false, 6)
+	area.PackStart(entry, false, false, 6)
+	d.ShowAll()
+	response := d.Run()
+	name, err := entry.GetText()
+	if err != nil {
+		return err
+	}
+	if response != gtk.RESPONSE_OK || name != ctx.Name {
+		return eksdefault.NotConfirmed
+	}
+	return nil
+}
+
 func showError(msg string) error {
 	c := new(chooser)
 	c.setupWindow()
@@ -197,6 +248,10 @@ func fetchContexts() (*kubeConfig, error) {
 		return nil, err
 	}
 	p.file = file
+	p.file.Caller = eksdefault.CallerGTK
+	p.file.ConfirmProtected = func(ctx *eksdefault.KubeContext) error {
+		return confirmProtected(ctx, file.AccountOf(ctx))
+	}
 	p.list = append(p.file.GetContextNames(), noContext)
 	_, p.currIdx, err = p.file.GetContextBy(file.CurrentContext)
 	if err != nil || p.currIdx == -2 { // -2 means no default set
@@ -214,6 +269,9 @@ func init() {
 
 func main() {
 	gtk.Init(&os.Args)
+	if _, err := eksdefault.RevertExpired(); err != nil {
+		log.Println(err)
+	}
 	p, err := fetchContexts()
 	if err != nil { // only profile related errors
 		if e := showError(err.Error()); e != nil {
@@ -228,4 +286,5 @@ func main() {
 	}
 	c.window.ShowAll()
 	gtk.Main()
+	p.file.Close()
 }
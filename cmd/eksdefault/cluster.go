@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+var forceFlag = cli.BoolFlag{
+	Name:  "force, f",
+	Usage: "Removes the entry even if contexts still use it.",
+}
+
+// withConfigFiles wraps every command with withConfigFile.
+func withConfigFiles(file *eksdefault.KubeConfig, cmds ...*cli.Command) []cli.Command {
+	wrapped := []cli.Command{}
+	for _, cmd := range cmds {
+		wrapped = append(wrapped, withConfigFile(*cmd, file))
+	}
+	return wrapped
+}
+
+// usedBy returns the contexts as comma separated list.
+func usedBy(contexts []string) string {
+	if len(contexts) < 1 {
+		return "-"
+	}
+	return strings.Join(contexts, ",")
+}
+
+// manageClusters lists, adds, removes and changes the clusters inside the kube config.
+func manageClusters(file *eksdefault.KubeConfig) *cli.Command {
+	completeFirst := func(c *cli.Context) {
+		if c.NArg() < 1 {
+			completeClusters(file)
+		}
+	}
+	return &cli.Command{
+		Name:    "cluster",
+		Aliases: []string{"clusters"},
+		Usage:   "'cluster add|rm|ls|set-server|set-ca': Manages the clusters inside the kube config.",
+		Subcommands: withConfigFiles(file,
+			&cli.Command{
+				Name:    "ls",
+				Aliases: []string{"list"},
+				Usage:   "'cluster ls': Lists the clusters together with the contexts using them.",
+				Action: func(c *cli.Context) error {
+					tbl := [][]string{}
+					for _, name := range file.ClusterNames() {
+						cl, _, err := file.GetClusterBy(name)
+						if err != nil {
+							return err
+						}
+						ca := ""
+						switch {
+						case len(cl.Cluster.CertificateAuthorityData) > 0:
+							ca = "embedded"
+						case len(cl.Cluster.CertificateAuthority) > 0:
+							ca = cl.Cluster.CertificateAuthority
+						}
+						tbl = append(tbl, []string{name, cl.Cluster.Server, ca, usedBy(file.ContextsUsing(name, ""))})
+					}
+					return printTabbed([]string{"CLUSTER", "SERVER", "CA", "CONTEXTS"}, tbl)
+				},
+			},
+			&cli.Command{
+				Name:  "add",
+				Usage: "'cluster add <name> -s <server URL> [--ca <file> [--embed]]': Adds a new cluster.",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "server, s",
+						Usage: "URL of the API server.",
+					},
+					cli.StringFlag{
+						Name:  "ca",
+						Usage: "File containing the PEM encoded certificate authority.",
+					},
+					cli.BoolFlag{
+						Name:  "embed",
+						Usage: "Embeds the certificate authority into the kube config instead of referencing the file.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 || len(c.String("server")) < 1 {
+						return fmt.Errorf("the name of the new cluster and its server URL are required")
+					}
+					info := &eksdefault.ClusterInfo{Server: c.String("server")}
+					if len(c.String("ca")) > 0 {
+						if err := file.ReadClusterCA(info, c.String("ca"), c.Bool("embed")); err != nil {
+							return err
+						}
+					}
+					return file.AddCluster(eksdefault.KubeCluster{Name: c.Args().First(), Cluster: info})
+				},
+			},
+			&cli.Command{
+				Name:         "rm",
+				Aliases:      []string{"remove", "del"},
+				Usage:        "'cluster rm <name> [-f]': Removes the cluster, if no context uses it anymore or with '-f' anyway.",
+				Flags:        []cli.Flag{forceFlag},
+				BashComplete: completeFirst,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("the name of an existing cluster is required")
+					}
+					return file.RemoveCluster(c.Args().First(), c.Bool("force"))
+				},
+			},
+			&cli.Command{
+				Name:         "set-server",
+				Usage:        "'cluster set-server <name> <server URL>': Changes the URL of the API server.",
+				BashComplete: completeFirst,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 2 {
+						return fmt.Errorf("the name of an existing cluster and the server URL are required")
+					}
+					return file.SetClusterServer(c.Args().First(), c.Args().Get(1))
+				},
+			},
+			&cli.Command{
+				Name:  "set-ca",
+				Usage: "'cluster set-ca <name> <file> [--embed]': Changes the certificate authority of the cluster.",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "embed",
+						Usage: "Embeds the certificate authority into the kube config instead of referencing the file.",
+					},
+				},
+				BashComplete: completeFirst,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 2 {
+						return fmt.Errorf("the name of an existing cluster and the certificate file are required")
+					}
+					return file.SetClusterCA(c.Args().First(), c.Args().Get(1), c.Bool("embed"))
+				},
+			},
+		),
+	}
+}
+
+// manageUsers lists, adds, removes and changes the users inside the kube config.
+func manageUsers(file *eksdefault.KubeConfig) *cli.Command {
+	completeFirst := func(c *cli.Context) {
+		if c.NArg() < 1 {
+			completeUsers(file)
+		}
+	}
+	execFlags := []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "env, e",
+			Usage: "Environment variable 'NAME=VALUE' passed to the credential plugin. Can be repeated.",
+		},
+		cli.StringFlag{
+			Name:  "api-version",
+			Usage: "API version of the credential plugin. Default is 'client.authentication.k8s.io/v1beta1'.",
+		},
+	}
+	execConfig := func(c *cli.Context, args []string) (eksdefault.ExecConfig, error) {
+		exec := eksdefault.ExecConfig{APIVersion: c.String("api-version")}
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+		if len(args) > 0 {
+			exec.Command, exec.Args = args[0], args[1:]
+		}
+		for _, e := range c.StringSlice("env") {
+			kv := strings.SplitN(e, "=", 2)
+			if len(kv) != 2 || len(kv[0]) < 1 {
+				return exec, fmt.Errorf("'%s' is not a valid environment variable; use 'NAME=VALUE'", e)
+			}
+			exec.Env = append(exec.Env, eksdefault.ExecEnv{Name: kv[0], Value: kv[1]})
+		}
+		return exec, nil
+	}
+	return &cli.Command{
+		Name:    "user",
+		Aliases: []string{"users"},
+		Usage:   "'user add|rm|ls|set-exec': Manages the users inside the kube config.",
+		Subcommands: withConfigFiles(file,
+			&cli.Command{
+				Name:    "ls",
+				Aliases: []string{"list"},
+				Usage:   "'user ls': Lists the users together with the contexts using them.",
+				Action: func(c *cli.Context) error {
+					tbl := [][]string{}
+					for _, name := range file.UserNames() {
+						u, _, err := file.GetUserBy(name)
+						if err != nil {
+							return err
+						}
+						auth := ""
+						if u.User.Exec != nil {
+							auth = strings.Join(append([]string{u.User.Exec.Command}, u.User.Exec.Args...), " ")
+						} else {
+							keys := []string{}
+							for k := range u.User.Extra {
+								keys = append(keys, k)
+							}
+							sort.Strings(keys)
+							auth = strings.Join(keys, ",")
+						}
+						tbl = append(tbl, []string{name, auth, usedBy(file.ContextsUsing("", name))})
+					}
+					return printTabbed([]string{"USER", "AUTH", "CONTEXTS"}, tbl)
+				},
+			},
+			&cli.Command{
+				Name:  "add",
+				Usage: "'user add <name> [-e NAME=VALUE] [-- <command> [<args>...]]': Adds a new user, optionally with a credential plugin.",
+				Flags: execFlags,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("the name of the new user is required")
+					}
+					name := c.Args().First()
+					exec, err := execConfig(c, c.Args().Tail())
+					if err != nil {
+						return err
+					}
+					if err := file.AddUser(eksdefault.KubeUser{Name: name}); err != nil || len(exec.Command) < 1 {
+						return err
+					}
+					return file.SetUserExec(name, exec)
+				},
+			},
+			&cli.Command{
+				Name:         "rm",
+				Aliases:      []string{"remove", "del"},
+				Usage:        "'user rm <name> [-f]': Removes the user, if no context uses it anymore or with '-f' anyway.",
+				Flags:        []cli.Flag{forceFlag},
+				BashComplete: completeFirst,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("the name of an existing user is required")
+					}
+					return file.RemoveUser(c.Args().First(), c.Bool("force"))
+				},
+			},
+			&cli.Command{
+				Name: "set-exec",
+				Usage: "'user set-exec <name> [-e NAME=VALUE] -- <command> [<args>...]': Changes the credential plugin of the user," +
+					" e.g. 'aws eks get-token --cluster-name <cluster>'.",
+				Flags:        execFlags,
+				BashComplete: completeFirst,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("the name of an existing user and the command of the credential plugin are required")
+					}
+					exec, err := execConfig(c, c.Args().Tail())
+					if err != nil {
+						return err
+					}
+					return file.SetUserExec(c.Args().First(), exec)
+				},
+			},
+		),
+	}
+}
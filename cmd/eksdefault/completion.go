@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// completionTimeout limits the requests to the Kubernetes API while completing.
+const completionTimeout = 2 * time.Second
+
+const (
+	bashCompletion = `# eksdefault bash completion; load it via: source <(eksdefault completion bash)
+_eksdefault_complete() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	if declare -F _get_comp_words_by_ref >/dev/null; then
+		_get_comp_words_by_ref -n : cur
+	fi
+	local IFS=$'\n'
+	COMPREPLY=( $(compgen -W "$("${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null | cut -f1)" -- "$cur") )
+	if declare -F __ltrim_colon_completions >/dev/null; then
+		__ltrim_colon_completions "$cur"
+	fi
+}
+complete -o default -F _eksdefault_complete eksdefault
+`
+	zshCompletion = `#compdef eksdefault
+# eksdefault zsh completion; load it via: source <(eksdefault completion zsh)
+_eksdefault() {
+	local -a opts
+	local line
+	for line in ${(f)"$(${words[1,CURRENT-1]} --generate-bash-completion 2>/dev/null)"}; do
+		opts+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+	done
+	_describe 'values' opts
+}
+compdef _eksdefault eksdefault
+`
+	fishCompletion = `# eksdefault fish completion; load it via: eksdefault completion fish | source
+complete -c eksdefault -f -a '(eksdefault (commandline -opc)[2..-1] --generate-bash-completion 2>/dev/null)'
+`
+	powershellCompletion = `# eksdefault PowerShell completion; load it via:
+# eksdefault completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName eksdefault -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+	if ($wordToComplete -ne '' -and $words.Count -gt 0) {
+		$words = $words[0..($words.Count - 2)]
+	}
+	& eksdefault @words --generate-bash-completion 2>$null | ForEach-Object {
+		$value, $description = $_ -split "` + "`" + `t", 2
+		if (-not $description) { $description = $value }
+		if ($value -like "$wordToComplete*") {
+			[System.Management.Automation.CompletionResult]::new($value, $value, 'ParameterValue', $description)
+		}
+	}
+}
+`
+)
+
+var (
+	completionScripts = map[string]string{
+		"bash":       bashCompletion,
+		"zsh":        zshCompletion,
+		"fish":       fishCompletion,
+		"powershell": powershellCompletion,
+	}
+	// profileFlags are the flags, which expect the name of an aws profile as value.
+	profileFlags = []string{"-p", "--p", "-profile", "--profile"}
+	// rawArgs keeps the unparsed arguments for completing flag values.
+	rawArgs = []string{}
+)
+
+// completeContexts adds all context names together with their ID and aws profile as
+// description to the output.
+func completeContexts(file *eksdefault.KubeConfig) {
+	for idx, c := range file.Contexts {
+		output += fmt.Sprintf("%s\tID %d, aws profile '%s'\n", c.Name, idx, c.AWSprofile)
+	}
+}
+
+// completeClusters adds the names of all clusters inside the kube config to the output.
+func completeClusters(file *eksdefault.KubeConfig) {
+	for _, n := range file.ClusterNames() {
+		output += fmt.Sprintf("%s\n", n)
+	}
+}
+
+// completeUsers adds the names of all users inside the kube config to the output.
+func completeUsers(file *eksdefault.KubeConfig) {
+	for _, n := range file.UserNames() {
+		output += fmt.Sprintf("%s\n", n)
+	}
+}
+
+// completeNamespaces adds all namespaces already used by a context and the namespaces of
+// the cluster of the current-context to the output. The latter are cached for a short time.
+func completeNamespaces(file *eksdefault.KubeConfig) {
+	names := file.GetNamespaces()
+	if len(file.CurrentContext) > 0 {
+		if live, err := file.CachedNamespaces(file.CurrentContext, completionTimeout); err == nil {
+			names = append(names, live...)
+		}
+	}
+	sort.Strings(names)
+	for idx, n := range names {
+		if idx == 0 || names[idx-1] != n {
+			output += fmt.Sprintf("%s\n", n)
+		}
+	}
+}
+
+// completeProfiles adds the names of all profiles inside the AWS credentials file to the output.
+func completeProfiles() {
+	names, err := eksdefault.GetProfileNames(options...)
+	if err != nil {
+		return
+	}
+	for _, n := range names {
+		output += fmt.Sprintf("%s\n", n)
+	}
+}
+
+// completeCommands adds the names of all visible commands to the output.
+func completeCommands(c *cli.Context) {
+	for _, cmd := range c.App.Commands {
+		if !cmd.Hidden {
+			output += fmt.Sprintf("%s\n", cmd.Name)
+		}
+	}
+}
+
+// withFlagCompletion wraps the completion of the command and completes the values of the
+// profile flags instead, if such a flag is the last argument.
+func withFlagCompletion(cmd cli.Command) cli.Command {
+	complete := cmd.BashComplete
+	if complete == nil && len(cmd.Subcommands) > 0 {
+		complete = completeCommands
+	}
+	cmd.BashComplete = func(c *cli.Context) {
+		if len(rawArgs) > 1 && inList(rawArgs[len(rawArgs)-2], profileFlags) {
+			completeProfiles()
+			return
+		}
+		if complete != nil {
+			complete(c)
+		}
+	}
+	return cmd
+}
+
+func inList(item string, list []string) bool {
+	for _, i := range list {
+		if item == i {
+			return true
+		}
+	}
+	return false
+}
+
+// completion prints the script, which enables the shell completion for the given shell.
+func completion() *cli.Command {
+	shells := []string{"bash", "zsh", "fish", "powershell"}
+	return &cli.Command{
+		Name:  "completion",
+		Usage: "'completion <bash|zsh|fish|powershell>': Prints the shell completion script for the given shell.",
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() < 1 {
+				output += strings.Join(shells, "\n") + "\n"
+			}
+		},
+		Action: func(c *cli.Context) error {
+			script, ok := completionScripts[strings.ToLower(c.Args().First())]
+			if !ok {
+				return fmt.Errorf("a shell is required; one of %s", strings.Join(shells, ", "))
+			}
+			output = script
+			return nil
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// applyConfig sets the environment variables of the settings in the config file, which are
+// not set already. So the flags overrule the environment and the environment overrules the
+// config file. The returned function removes the variables again.
+func applyConfig() (func(), error) {
+	applied := []string{}
+	restore := func() {
+		for _, name := range applied {
+			os.Unsetenv(name)
+		}
+	}
+	config, err := eksdefault.ReadConfig(options...)
+	if err != nil {
+		return restore, err
+	}
+	for name, value := range config.Environ() {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		os.Setenv(name, value)
+		applied = append(applied, name)
+	}
+	return restore, nil
+}
+
+// completeConfigKeys adds the keys of the config file to the output.
+func completeConfigKeys(c *cli.Context) {
+	if c.NArg() > 0 {
+		return
+	}
+	for _, key := range eksdefault.ConfigKeys() {
+		output += fmt.Sprintf("%s\t%s\n", key, eksdefault.ConfigEnv[key])
+	}
+}
+
+// manageConfig views and changes the config file of eksdefault.
+func manageConfig() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Views and changes the settings inside " + eksdefault.ConfigPath() + ".",
+		Subcommands: []cli.Command{
+			{
+				Name:  "view",
+				Usage: "'config view': Prints the settings of the config file.",
+				Action: func(c *cli.Context) error {
+					config, err := eksdefault.ReadConfig(options...)
+					if err != nil {
+						return err
+					}
+					content, err := yaml.Marshal(config)
+					if err != nil {
+						return err
+					}
+					output = fmt.Sprintf("# %s\n%s", config.Path, content)
+					return nil
+				},
+			},
+			{
+				Name:         "set",
+				Usage:        "'config set <key> <value>': Changes the setting; lists are comma separated and an empty value removes the setting.",
+				BashComplete: completeConfigKeys,
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return fmt.Errorf("the key and the value are required; keys are %v", eksdefault.ConfigKeys())
+					}
+					return setConfig(c, c.Args().Get(0), c.Args().Get(1))
+				},
+			},
+			{
+				Name:         "unset",
+				Usage:        "'config unset <key>': Removes the setting.",
+				BashComplete: completeConfigKeys,
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("the key is required; keys are %v", eksdefault.ConfigKeys())
+					}
+					return setConfig(c, c.Args().First(), "")
+				},
+			},
+		},
+	}
+}
+
+// setConfig changes the setting in the config file or prints the change as unified diff in
+// dry-run mode.
+func setConfig(c *cli.Context, key, value string) error {
+	config, err := eksdefault.ReadConfig(options...)
+	if err != nil {
+		return err
+	}
+	if err := config.Set(key, value); err != nil {
+		return err
+	}
+	if c.GlobalBool("dry-run") {
+		change, err := config.Change()
+		if err != nil {
+			return err
+		}
+		output = change.Diff()
+		return nil
+	}
+	return config.Save()
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+type (
+	// prefixWriter writes every complete line with the prefix to the shared writer.
+	prefixWriter struct {
+		mu     *sync.Mutex
+		w      io.Writer
+		prefix string
+		buf    bytes.Buffer
+	}
+
+	// eachRun is the result of running the command for one context.
+	eachRun struct {
+		context  string
+		exitCode int
+		duration time.Duration
+		err      error
+	}
+)
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf.Write(data)
+	for {
+		idx := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if idx < 0 {
+			return len(data), nil
+		}
+		line := p.buf.Next(idx + 1)
+		p.mu.Lock()
+		_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+		p.mu.Unlock()
+		if err != nil {
+			return len(data), err
+		}
+	}
+}
+
+// Flush writes the last line, if it does not end with a newline.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		p.mu.Lock()
+		fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf.String())
+		p.mu.Unlock()
+		p.buf.Reset()
+	}
+}
+
+// splitCommand separates the contexts given as arguments from the command following '--'.
+// The raw arguments are used, because the flag parser drops '--' if it follows a flag.
+func splitCommand(c *cli.Context) ([]string, []string) {
+	command := []string{}
+	for idx, arg := range rawArgs {
+		if arg == "--" {
+			command = rawArgs[idx+1:]
+			break
+		}
+	}
+	args := c.Args()
+	n := len(args) - len(command)
+	if n < 0 {
+		n = 0
+	}
+	contexts := args[:n]
+	if len(contexts) > 0 && contexts[len(contexts)-1] == "--" {
+		contexts = contexts[:len(contexts)-1]
+	}
+	return contexts, command
+}
+
+// runIn runs the command with a kube config containing only the given context and with
+// its aws-profile as AWS_PROFILE.
+func runIn(file *eksdefault.KubeConfig, name, dir string, command []string, stdout, stderr io.Writer) eachRun {
+	run := eachRun{context: name, exitCode: -1}
+	extracted, err := file.Extract(name)
+	if err != nil {
+		run.err = err
+		return run
+	}
+	content, err := extracted.Content()
+	if err != nil {
+		run.err = err
+		return run
+	}
+	config, err := ioutil.TempFile(dir, "config")
+	if err != nil {
+		run.err = err
+		return run
+	}
+	defer config.Close()
+	if _, err := config.Write(content); err != nil {
+		run.err = err
+		return run
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+config.Name(), "EKSDEFAULT_CONTEXT="+name)
+	if profile := extracted.Contexts[0].AWSprofile; len(profile) > 0 {
+		cmd.Env = append(cmd.Env, "AWS_PROFILE="+profile)
+	}
+	if len(credentialsFile) > 0 {
+		cmd.Env = append(cmd.Env, "AWS_SHARED_CREDENTIALS_FILE="+credentialsFile)
+	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	start := time.Now()
+	err = cmd.Run()
+	run.duration = time.Since(start)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		run.exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		run.err = err
+	} else {
+		run.exitCode = 0
+	}
+	return run
+}
+
+// eachContext runs a command for every selected context in parallel. Every run gets its own
+// kube config, so the current-context and the default AWS profile stay untouched.
+func eachContext(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "each",
+		Aliases: []string{"foreach"},
+		Usage: "'each [<context>|<ID> ...|-l <selector>|--all] -- <command> [<args>...]': Runs the command for every context in parallel" +
+			" with KUBECONFIG and AWS_PROFILE set for this context. Prints a summary of the exit codes.",
+		Flags: []cli.Flag{
+			selectorFlag,
+			cli.BoolFlag{
+				Name:  "all, a",
+				Usage: "Runs the command for all contexts.",
+			},
+			cli.IntFlag{
+				Name:   "parallel, j",
+				Value:  4,
+				Usage:  "Maximal number of commands running at the same time.",
+				EnvVar: "EKSDEFAULT_PARALLEL",
+			},
+			cli.BoolFlag{
+				Name:  "group, g",
+				Usage: "Prints the output of a run as a block once it finished instead of prefixing every line.",
+			},
+		},
+		BashComplete: func(c *cli.Context) {
+			completeContexts(file)
+		},
+		Action: func(c *cli.Context) error {
+			contexts, command := splitCommand(c)
+			if len(command) < 1 {
+				return fmt.Errorf("the command is required after '--'")
+			}
+			names := file.GetContextNames()
+			if !c.Bool("all") {
+				if len(contexts) < 1 && len(c.String("selector")) < 1 {
+					return fmt.Errorf("the contexts are required; name them, use '--selector' or '--all'")
+				}
+				var err error
+				if names, err = contextArgs(c, file, contexts); err != nil {
+					return err
+				}
+			}
+			parallel := c.Int("parallel")
+			if parallel < 1 {
+				parallel = 1
+			}
+			dir, err := ioutil.TempDir("", "eksdefault-each")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(dir)
+
+			var (
+				wg      sync.WaitGroup
+				mu      sync.Mutex
+				slots   = make(chan struct{}, parallel)
+				results = make([]eachRun, len(names))
+			)
+			for idx, name := range names {
+				wg.Add(1)
+				go func(idx int, name string) {
+					defer wg.Done()
+					slots <- struct{}{}
+					defer func() { <-slots }()
+					if c.Bool("group") {
+						var out bytes.Buffer
+						results[idx] = runIn(file, name, dir, command, &out, &out)
+						mu.Lock()
+						fmt.Fprintf(stdout, "=== %s (exit %d)\n%s", name, results[idx].exitCode, out.String())
+						mu.Unlock()
+						return
+					}
+					prefix := fmt.Sprintf("[%s] ", name)
+					o := &prefixWriter{mu: &mu, w: stdout, prefix: prefix}
+					e := &prefixWriter{mu: &mu, w: stderr, prefix: prefix}
+					results[idx] = runIn(file, name, dir, command, o, e)
+					o.Flush()
+					e.Flush()
+				}(idx, name)
+			}
+			wg.Wait()
+
+			failed, tbl := 0, [][]string{}
+			for _, r := range results {
+				exitCode := strconv.Itoa(r.exitCode)
+				if r.err != nil {
+					exitCode = r.err.Error()
+				}
+				if r.exitCode != 0 {
+					failed++
+				}
+				tbl = append(tbl, []string{r.context, exitCode, r.duration.Round(time.Millisecond).String()})
+			}
+			if err := printTabbed([]string{"CONTEXT", "EXIT", "DURATION"}, tbl); err != nil {
+				return err
+			}
+			fmt.Fprint(stderr, output)
+			output = ""
+			if failed > 0 {
+				return fmt.Errorf("the command failed for %d of %d contexts", failed, len(results))
+			}
+			return nil
+		},
+	}
+}
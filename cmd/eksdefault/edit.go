@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// editor returns the command line of the editor configured via VISUAL or EDITOR.
+func editor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.Fields(os.Getenv(env)); len(e) > 0 {
+			return e
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editYAML opens the context as YAML inside the editor and returns the edited version.
+func editYAML(ctx eksdefault.KubeContext) (eksdefault.KubeContext, error) {
+	edited := eksdefault.KubeContext{}
+	content, err := yaml.Marshal(&ctx)
+	if err != nil {
+		return edited, err
+	}
+	f, err := ioutil.TempFile("", "eksdefault-*.yaml")
+	if err != nil {
+		return edited, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return edited, err
+	}
+	if err := f.Close(); err != nil {
+		return edited, err
+	}
+	e := editor()
+	cmd := exec.Command(e[0], append(e[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return edited, fmt.Errorf("the editor '%s' failed: %v", strings.Join(e, " "), err)
+	}
+	if content, err = ioutil.ReadFile(f.Name()); err != nil {
+		return edited, err
+	}
+	if err := yaml.UnmarshalStrict(content, &edited); err != nil {
+		return edited, fmt.Errorf("the edited context is not valid: %v", err)
+	}
+	return edited, nil
+}
+
+// editContext changes the settings of an existing context either via flags or inside the
+// editor.
+func editContext(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "edit",
+		Aliases: []string{"e", "change"},
+		Usage: "'edit <context>|<ID>|-l <selector> [-u <user name>|-n <namespace>|-c <cluster name>|-p <aws profile>|-e]':" +
+			" Changes the settings of the given context. With '-e' the context is opened as YAML in $EDITOR.",
+		Flags: append([]cli.Flag{
+			selectorFlag,
+			cli.BoolFlag{
+				Name:  "edit, e",
+				Usage: "Opens the context as YAML in $EDITOR after the other flags were applied.",
+			},
+		}, addFlags...),
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() < 1 {
+				completeContexts(file)
+			}
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 && len(c.String("selector")) < 1 {
+				return fmt.Errorf(
+					"the ID or name of an existing context is required",
+				)
+			}
+			name, err := contextArg(c, file, c.Args().First())
+			if err != nil {
+				return err
+			}
+			ctx, _, err := file.GetContextBy(name)
+			if err != nil {
+				return err
+			}
+			updated := *ctx
+			settings := *ctx.Context
+			updated.Context = &settings
+			changed := false
+			for _, f := range []struct {
+				flag  string
+				value *string
+			}{
+				{"user", &settings.User},
+				{"cluster", &settings.Cluster},
+				{"namespace", &settings.Namespace},
+				{"profile", &updated.AWSprofile},
+			} {
+				if c.IsSet(f.flag) {
+					*f.value = c.String(f.flag)
+					changed = true
+				}
+			}
+			if c.Bool("edit") {
+				if updated, err = editYAML(updated); err != nil {
+					return err
+				}
+			} else if !changed {
+				return fmt.Errorf("nothing to change; use the flags -u, -c, -n, -p or -e")
+			}
+			return file.UpdateContext(name, updated)
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// revertExpired switches back to the safe context, if a switch made with 'set --for'
+// expired. Every invocation of eksdefault without --dry-run runs it first.
+func revertExpired() {
+	e, err := eksdefault.RevertExpired(options...)
+	if err != nil {
+		fmt.Fprintf(stderr, "[EKSDEFAULT][WARN] unable to revert the expired context: %v.\n", err)
+		return
+	}
+	if e != nil {
+		fmt.Fprintf(stderr, "The context '%s' expired; switched back to %s.\n", e.Context, revertTarget(e))
+	}
+}
+
+func revertTarget(e *eksdefault.Expiry) string {
+	if len(e.RevertTo) < 1 {
+		return "no current-context"
+	}
+	return fmt.Sprintf("'%s'", e.RevertTo)
+}
+
+// expiryInfo returns the remaining time of the current-context or an empty string, if it
+// does not expire.
+func expiryInfo(current string) string {
+	e, err := eksdefault.ReadExpiry(options...)
+	if err != nil || e.Context != current {
+		return ""
+	}
+	return fmt.Sprintf("expires in %v, then %s", e.Remaining(), revertTarget(e))
+}
+
+// startWatcher runs 'eksdefault watch' in the background.
+func startWatcher() error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(self, append(globalArgs, "watch")...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// watchExpiry waits until the switch made with 'set --for' expires and switches back.
+func watchExpiry() *cli.Command {
+	return &cli.Command{
+		Name: "watch",
+		Usage: "'watch [-i <interval>]': Waits until the switch made with 'set --for' expires and switches back to the safe context." +
+			" Ends afterwards or as soon as nothing expires anymore.",
+		Flags: []cli.Flag{
+			cli.DurationFlag{
+				Name:  "interval, i",
+				Value: 10 * time.Second,
+				Usage: "Checks at least in this interval, whether the expiry was changed or dropped.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.GlobalBool("dry-run") {
+				return fmt.Errorf("'watch' does not support --dry-run")
+			}
+			for {
+				e, err := eksdefault.ReadExpiry(options...)
+				if err == eksdefault.NoExpiry {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				wait := time.Until(e.Until)
+				if interval := c.Duration("interval"); wait > interval {
+					wait = interval
+				}
+				time.Sleep(wait)
+				reverted, err := eksdefault.RevertExpired(options...)
+				if err != nil {
+					return err
+				}
+				if reverted != nil {
+					output = fmt.Sprintf("The context '%s' expired; switched back to %s.\n", reverted.Context, revertTarget(reverted))
+					return nil
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// extractContext writes a self-contained kube config for a single context, which can be
+// handed to CI jobs or containers.
+func extractContext(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "extract",
+		Aliases: []string{"minify"},
+		Usage: "'extract <context>|<ID>|-l <selector> [-o <file>]': Writes a kube config with only the given context, its cluster and user." +
+			" Certificate files are embedded and the aws profile is set as AWS_PROFILE for the credential plugin.",
+		Flags: []cli.Flag{
+			selectorFlag,
+			cli.StringFlag{
+				Name:  "output, o",
+				Value: "-",
+				Usage: "File to write the kube config to; '-' prints it.",
+			},
+		},
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() < 1 {
+				completeContexts(file)
+			}
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 && len(c.String("selector")) < 1 {
+				return fmt.Errorf(
+					"the ID or name of an existing context is required",
+				)
+			}
+			name, err := contextArg(c, file, c.Args().First())
+			if err != nil {
+				return err
+			}
+			if out := c.String("output"); out != "-" {
+				return file.ExtractTo(name, out)
+			}
+			extracted, err := file.Extract(name)
+			if err != nil {
+				return err
+			}
+			content, err := extracted.Content()
+			if err != nil {
+				return err
+			}
+			output = string(content)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// getHistory prints the recorded switches of the current-context; the latest first.
+func getHistory() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "'history [-m <max entries>]': Prints the recorded switches of the current-context; the latest first.",
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "max, m",
+				Usage: "Prints only the given number of entries.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			entries, err := eksdefault.ReadHistory(options...)
+			if err != nil {
+				return err
+			}
+			tbl := [][]string{}
+			for n := 1; n <= len(entries); n++ {
+				if max := c.Int("max"); max > 0 && n > max {
+					break
+				}
+				e := entries[len(entries)-n]
+				tbl = append(tbl, []string{
+					fmt.Sprintf("%d", n),
+					e.Time.Local().Format("2006-01-02 15:04:05"),
+					e.From,
+					e.To,
+					e.Profile,
+					e.Caller,
+				})
+			}
+			return printTabbed([]string{"N", "TIME", "FROM", "TO", "AWS PROFILE", "CALLER"}, tbl)
+		},
+	}
+}
+
+// setContextBack switches to the context, which was left by the N-th last switch as shown by
+// 'eksdefault history'.
+func setContextBack(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:  "back",
+		Usage: "'back [<N>]': Changes the current-context to the FROM context of the N-th entry in 'eksdefault history'. Default is 1.",
+		Flags: []cli.Flag{yesFlag},
+		Action: func(c *cli.Context) error {
+			n := 1
+			if c.NArg() > 0 {
+				var err error
+				if n, err = strconv.Atoi(c.Args().First()); err != nil {
+					return fmt.Errorf("'%s' is not a number. Run 'eksdefault history' to get the entry numbers", c.Args().First())
+				}
+			}
+			return file.SetContextBack(n)
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+var selectorFlag = cli.StringFlag{
+	Name:  "selector, l",
+	Usage: "Selects the contexts by their labels like 'env=prod,team in (payments,core),!legacy'.",
+}
+
+// contextArg returns the name of the context given by its name or ID or, with --selector,
+// the single context matching the selector.
+func contextArg(c *cli.Context, file *eksdefault.KubeConfig, arg string) (string, error) {
+	selector := c.String("selector")
+	if len(selector) < 1 {
+		return idToName(arg, file)
+	}
+	if len(arg) > 0 {
+		return "", fmt.Errorf("either a context or '--selector' is allowed")
+	}
+	names, err := file.SelectContexts(selector)
+	if err != nil {
+		return "", err
+	}
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no context matches the selector '%s'", selector)
+	case 1:
+		return names[0], nil
+	}
+	return "", fmt.Errorf("the selector '%s' matches the contexts %s, but a single one is required", selector, strings.Join(names, ", "))
+}
+
+// contextArgs returns the names of the contexts given by their names or IDs followed by the
+// contexts matching --selector.
+func contextArgs(c *cli.Context, file *eksdefault.KubeConfig, args []string) ([]string, error) {
+	names := []string{}
+	for _, arg := range args {
+		name, err := idToName(arg, file)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	selector := c.String("selector")
+	if len(selector) < 1 {
+		return names, nil
+	}
+	selected, err := file.SelectContexts(selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) < 1 {
+		return nil, fmt.Errorf("no context matches the selector '%s'", selector)
+	}
+	for _, name := range selected {
+		if !inList(name, names) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// labelContexts adds, changes or removes the labels of contexts or prints them.
+func labelContexts(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "label",
+		Aliases: []string{"labels"},
+		Usage: "'label <context>|<ID> ...|-l <selector> [<key>=<value> ...] [<key>- ...]': Sets or with '<key>-' removes labels" +
+			" of the contexts. Without changes the labels are printed.",
+		Flags: []cli.Flag{selectorFlag},
+		BashComplete: func(c *cli.Context) {
+			completeContexts(file)
+		},
+		Action: func(c *cli.Context) error {
+			contexts, changes := []string{}, []string{}
+			for _, arg := range c.Args() {
+				if strings.Contains(arg, "=") || strings.HasSuffix(arg, "-") {
+					changes = append(changes, arg)
+				} else {
+					contexts = append(contexts, arg)
+				}
+			}
+			if len(contexts) < 1 && len(c.String("selector")) < 1 {
+				if len(changes) > 0 {
+					return fmt.Errorf("the ID or name of a context or '--selector' is required")
+				}
+				contexts = file.GetContextNames()
+			}
+			names, err := contextArgs(c, file, contexts)
+			if err != nil {
+				return err
+			}
+			if len(changes) < 1 {
+				tbl := [][]string{}
+				for _, name := range names {
+					ctx, _, err := file.GetContextBy(name)
+					if err != nil {
+						return err
+					}
+					tbl = append(tbl, []string{name, eksdefault.FormatLabels(ctx.Labels)})
+				}
+				return printTabbed([]string{"KUBE CONTEXT", "LABELS"}, tbl)
+			}
+			set, remove, err := eksdefault.ParseLabelChanges(changes)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if err := file.SetLabels(name, set, remove); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -4,17 +4,29 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/peterbueschel/eksdefault"
 	"github.com/urfave/cli"
 )
 
 var (
+	// options locate the kube config, the AWS credentials file and the config file; they are
+	// set by the global flags.
+	options []eksdefault.Option
+	// globalArgs are the global flags passed on to the background watcher.
+	globalArgs []string
+	// credentialsFile is given by --aws-credentials-file and passed on to the commands run by
+	// 'each' and 'exec'.
+	credentialsFile string
+
 	addFlags = []cli.Flag{
 		cli.StringFlag{
 			Name:  "user, u",
@@ -48,7 +60,7 @@ var (
 // either use the ID or the context name.
 func idToName(str string, file *eksdefault.KubeConfig) (string, error) {
 	if id, err := strconv.Atoi(str); err == nil {
-		if len(file.Contexts) < id {
+		if id < 0 || len(file.Contexts) <= id {
 			return "", fmt.Errorf("The ID '%d' does not exists. Run 'eksdefault ls' to get the correct IDs", id)
 		}
 		return file.Contexts[id].Name, nil
@@ -102,9 +114,13 @@ func getCurrentContext(file *eksdefault.KubeConfig) *cli.Command {
 	return &cli.Command{
 		Name:    "is",
 		Aliases: []string{"current"},
-		Usage:   "'is': Prints the current-context.",
+		Usage:   "'is': Prints the current-context and the remaining time, if it expires.",
 		Action: func(c *cli.Context) error {
 			if cc := file.CurrentContext; len(cc) > 0 {
+				if expiry := expiryInfo(cc); len(expiry) > 0 {
+					output = fmt.Sprintf("%v (%s)\n", cc, expiry)
+					return nil
+				}
 				output = fmt.Sprintf("%v\n", file.CurrentContext)
 				return nil
 			}
@@ -121,23 +137,83 @@ func setDefaultContext(file *eksdefault.KubeConfig) *cli.Command {
 	return &cli.Command{
 		Name:    "set",
 		Aliases: []string{"to", "use", "use-current"},
-		Usage:   "'set <context>': Changes the current-context to the given context name.",
-		Action: func(c *cli.Context) error {
+		Usage: "'set <context>|-|-l <selector> [--for <duration>]': Changes the current-context to the given context name or with '-' back to the previous one." +
+			" With '--for' the switch is reverted after the duration.",
+		Flags: []cli.Flag{
+			yesFlag,
+			selectorFlag,
+			cli.DurationFlag{
+				Name:  "for",
+				Usage: "Switches back to the safe context after the duration like '30m'.",
+			},
+			cli.StringFlag{
+				Name:   "revert-to",
+				Usage:  "The safe context used after '--for' expired. Default is the current one.",
+				EnvVar: "EKSDEFAULT_SAFE_CONTEXT",
+			},
+			cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Starts 'eksdefault watch' in the background to switch back right on time.",
+			},
+			cli.StringFlag{
+				Name:   "binding",
+				Value:  "global",
+				Usage:  "With 'shell' only the variables binding the current shell are printed; load them via 'eval \"$(eksdefault set --binding shell <context>)\"'.",
+				EnvVar: "EKSDEFAULT_BINDING",
+			},
+			shellFlag,
+		},
+		BashComplete: func(c *cli.Context) {
 			if c.NArg() < 1 {
+				completeContexts(file)
+			}
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 && len(c.String("selector")) < 1 {
 				return fmt.Errorf(
 					"the ID or name of an existing context is required",
 				)
 			}
-			name, err := idToName(c.Args().First(), file)
+			duration := c.Duration("for")
+			if c.Args().First() == "-" {
+				if duration > 0 {
+					return fmt.Errorf("'--for' requires the ID or name of a context")
+				}
+				return file.SetPreviousContext()
+			}
+			switch c.String("binding") {
+			case "global":
+			case "shell":
+				if duration > 0 {
+					return fmt.Errorf("'--for' requires the global binding")
+				}
+				name, err := contextArg(c, file, c.Args().First())
+				if err != nil {
+					return err
+				}
+				output, err = bindShell(c.String("shell"), file, &eksdefault.Pin{Context: name})
+				return err
+			default:
+				return fmt.Errorf("unknown binding '%s'; use 'global' or 'shell'", c.String("binding"))
+			}
+			name, err := contextArg(c, file, c.Args().First())
 			if err != nil {
 				return err
 			}
-			if err := file.SetContextTo(name); err != nil {
+			if duration > 0 {
+				err = file.SetContextFor(name, duration, c.String("revert-to"))
+			} else {
+				err = file.SetContextTo(name)
+			}
+			if err != nil {
 				if err == eksdefault.NoProfilSet {
-					return fmt.Errorf("%v.\nYou can run also 'eksdefault profile <aws profile> %s' to set an AWS profile for this context", err, c.Args().First())
+					return fmt.Errorf("%v.\nYou can run also 'eksdefault profile <aws profile> %s' to set an AWS profile for this context", err, name)
 				}
 				return fmt.Errorf("%v", err)
 			}
+			if duration > 0 && c.Bool("watch") && !file.DryRun {
+				return startWatcher()
+			}
 			return nil
 		},
 	}
@@ -148,15 +224,24 @@ func useProfile(file *eksdefault.KubeConfig) *cli.Command {
 	return &cli.Command{
 		Name:    "profile",
 		Aliases: []string{"p", "use-profile", "pr"},
-		Usage:   "'n <aws profile> [<context>]': Changes the aws profile of a given context. If no context was given the current one will be used.",
+		Usage:   "'n <aws profile> [<context>|-l <selector>]': Changes the aws profile of a given context. If no context was given the current one will be used.",
+		Flags:   []cli.Flag{selectorFlag},
+		BashComplete: func(c *cli.Context) {
+			switch c.NArg() {
+			case 0:
+				completeProfiles()
+			case 1:
+				completeContexts(file)
+			}
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 1 {
 				return fmt.Errorf(
 					"the name of an existing aws profile is required and (optional the context)",
 				)
 			}
-			if c.NArg() > 1 {
-				name, err := idToName(c.Args().Get(1), file)
+			if c.NArg() > 1 || len(c.String("selector")) > 0 {
+				name, err := contextArg(c, file, c.Args().Get(1))
 				if err != nil {
 					return err
 				}
@@ -172,21 +257,95 @@ func useNamespace(file *eksdefault.KubeConfig) *cli.Command {
 	return &cli.Command{
 		Name:    "namespace",
 		Aliases: []string{"n", "use-namespace", "ns"},
-		Usage:   "'n <namespace> [<context>|<ID>]': Changes the namespace of a given context. If no context was given the current one will be used.",
+		Usage: "'n <namespace>|- [<context>|<ID>|-l <selector>]': Changes the namespace of a given context. If no context was given the current one will be used." +
+			" '-' switches back to the previous namespace of the context." +
+			" 'n --list|--recent [<context>|<ID>]' lists the namespaces of the cluster or selects a recent one.",
+		Flags: []cli.Flag{
+			selectorFlag,
+			cli.BoolFlag{
+				Name:  "list",
+				Usage: "Lists the namespaces of the cluster; they are cached for a short time.",
+			},
+			cli.BoolFlag{
+				Name:  "recent",
+				Usage: "Selects one of the namespaces used before by the context.",
+			},
+			cli.BoolFlag{
+				Name:  "refresh",
+				Usage: "Ignores the cached namespaces.",
+			},
+			cli.BoolFlag{
+				Name:   "validate",
+				Usage:  "Checks via the Kubernetes API that the namespace exists.",
+				EnvVar: "EKSDEFAULT_VALIDATE_NAMESPACE",
+			},
+			cli.DurationFlag{
+				Name:  "timeout, t",
+				Value: 5 * time.Second,
+				Usage: "Timeout of the requests to the Kubernetes API.",
+			},
+		},
+		BashComplete: func(c *cli.Context) {
+			switch {
+			case (c.Bool("list") || c.Bool("recent")) && c.NArg() == 0:
+				completeContexts(file)
+			case c.NArg() == 0:
+				completeNamespaces(file)
+			case c.NArg() == 1:
+				completeContexts(file)
+			}
+		},
 		Action: func(c *cli.Context) error {
+			if c.Bool("recent") {
+				name := file.CurrentContext
+				if c.NArg() > 0 || len(c.String("selector")) > 0 {
+					var err error
+					if name, err = contextArg(c, file, c.Args().First()); err != nil {
+						return err
+					}
+				}
+				return pickRecentNamespace(file, name)
+			}
+			if c.Bool("list") {
+				name := file.CurrentContext
+				if c.NArg() > 0 || len(c.String("selector")) > 0 {
+					var err error
+					if name, err = contextArg(c, file, c.Args().First()); err != nil {
+						return err
+					}
+				}
+				list := file.CachedNamespaces
+				if c.Bool("refresh") {
+					list = file.ListNamespaces
+				}
+				namespaces, err := list(name, c.Duration("timeout"))
+				if err != nil {
+					return err
+				}
+				output = strings.Join(namespaces, "\n") + "\n"
+				return nil
+			}
 			if c.NArg() < 1 {
 				return fmt.Errorf(
 					"the name of a namespace is required and (optional the context or its ID)",
 				)
 			}
-			if c.NArg() > 1 {
-				name, err := idToName(c.Args().Get(1), file)
-				if err != nil {
+			name := file.CurrentContext
+			if c.NArg() > 1 || len(c.String("selector")) > 0 {
+				var err error
+				if name, err = contextArg(c, file, c.Args().Get(1)); err != nil {
+					return err
+				}
+			}
+			if c.Args().First() == "-" {
+				return file.SetPreviousNamespace(name)
+			}
+			if c.Bool("validate") {
+				if err := file.CheckNamespace(name, c.Args().First(), c.Duration("timeout")); err != nil {
 					return err
 				}
-				return file.AddNamespaceTo(name, c.Args().First())
 			}
-			return file.AddNamespaceTo(file.CurrentContext, c.Args().First())
+			return file.AddNamespaceTo(name, c.Args().First())
 		},
 	}
 }
@@ -208,6 +367,11 @@ func copyContext(file *eksdefault.KubeConfig) *cli.Command {
 			" Copy the current context as a new context to the kube config file and overwrites the (if given) " +
 			"the specific settings for user, cluster, namespace or profile.",
 		Flags: addFlags,
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() < 1 {
+				completeContexts(file)
+			}
+		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 1 {
 				return missingNewContextName
@@ -231,8 +395,7 @@ func copyContext(file *eksdefault.KubeConfig) *cli.Command {
 					Cluster:   overwrite(c.String("cluster"), cc.Context.Cluster),
 				},
 			}
-			file.Contexts = append(file.Contexts, ctx)
-			return file.SaveContexts()
+			return file.AddContext(ctx)
 		},
 	}
 }
@@ -262,80 +425,384 @@ func addContext(file *eksdefault.KubeConfig) *cli.Command {
 					Cluster:   c.String("cluster"),
 				},
 			}
-			file.Contexts = append(file.Contexts, ctx)
-			return file.SaveContexts()
+			return file.AddContext(ctx)
 		},
 	}
 }
 
-// getContexts prints the available contexts either as a list or as a table.
+// listColumn is a column of the table printed by 'list'.
+type listColumn struct {
+	header string
+	value  func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string
+}
+
+var (
+	listColumns = map[string]listColumn{
+		"id": {"ID", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return strconv.Itoa(idx)
+		}},
+		"current": {"CURRENT", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			if c.Name == file.CurrentContext {
+				return "*"
+			}
+			return ""
+		}},
+		"context": {"KUBE CONTEXT", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return c.Name
+		}},
+		"profile": {"AWS PROFILE", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return c.AWSprofile
+		}},
+		"cluster": {"CLUSTER", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return c.Context.Cluster
+		}},
+		"user": {"USER", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return c.Context.User
+		}},
+		"namespace": {"NAMESPACE", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return c.Context.Namespace
+		}},
+		"labels": {"LABELS", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return eksdefault.FormatLabels(c.Labels)
+		}},
+		"last-used": {"LAST USED", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return lastUsed(file, c.Name)
+		}},
+		"protected": {"PROTECTED", func(file *eksdefault.KubeConfig, idx int, c eksdefault.KubeContext) string {
+			return yesNo(file.IsProtected(&c))
+		}},
+	}
+	defaultListColumns = []string{"id", "current", "context", "profile", "cluster", "user", "namespace"}
+)
+
+// lastUsed returns the time of the latest switch to the context as RFC 3339 timestamp or an
+// empty string, if the history has none.
+func lastUsed(file *eksdefault.KubeConfig, name string) string {
+	used, err := file.LastUsed()
+	if t, ok := used[name]; err == nil && ok {
+		return t.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// listColumnNames returns the names of all columns of the 'list' table in alphabetical order.
+func listColumnNames() []string {
+	names := []string{}
+	for name := range listColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printContexts prints the contexts as table with the given comma separated columns.
+func printContexts(file *eksdefault.KubeConfig, contexts []eksdefault.KubeContext, columns string, showLabels bool) error {
+	names := []string{}
+	for _, name := range strings.Split(columns, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) < 1 {
+			continue
+		}
+		if _, ok := listColumns[name]; !ok {
+			return fmt.Errorf("unknown column '%s'; use any of %s", name, strings.Join(listColumnNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	if showLabels && !inList("labels", names) {
+		names = append(names, "labels")
+	}
+	header := []string{}
+	for _, name := range names {
+		header = append(header, listColumns[name].header)
+	}
+	tbl := [][]string{}
+	for _, c := range contexts {
+		_, idx, err := file.GetContextBy(c.Name)
+		if err != nil {
+			return err
+		}
+		row := []string{}
+		for _, name := range names {
+			row = append(row, listColumns[name].value(file, idx, c))
+		}
+		tbl = append(tbl, row)
+	}
+	return printTabbed(header, tbl)
+}
+
+// getContexts prints the available contexts either as a list or as a table. Flags let you
+// filter and sort the contexts; the IDs stay the same as without any filter.
 func getContexts(file *eksdefault.KubeConfig) *cli.Command {
 	return &cli.Command{
 		Name:    "list",
 		Aliases: []string{"ls", "contexts"},
-		Flags: []cli.Flag{cli.BoolFlag{
-			Name:   "short, s",
-			Usage:  "Prints only the context names as list.",
-			EnvVar: "EKSDEFAULT_SHORT_INFO",
-		}},
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:   "short, s",
+				Usage:  "Prints only the context names as list; same as '--output short'.",
+				EnvVar: "EKSDEFAULT_SHORT_INFO",
+			},
+			cli.StringFlag{
+				Name:   "output, o",
+				Value:  "table",
+				Usage:  "Prints the contexts as 'table' or only their names with 'short'.",
+				EnvVar: "EKSDEFAULT_OUTPUT",
+			},
+			cli.StringFlag{
+				Name:   "columns",
+				Value:  strings.Join(defaultListColumns, ","),
+				Usage:  "Comma separated columns of the table; any of " + strings.Join(listColumnNames(), ", ") + ".",
+				EnvVar: "EKSDEFAULT_LIST_COLUMNS",
+			},
+			cli.StringFlag{
+				Name:  "name",
+				Usage: "Shows only contexts whose name matches the glob pattern.",
+			},
+			cli.StringFlag{
+				Name:  "regex, r",
+				Usage: "Shows only contexts whose name matches the regular expression.",
+			},
+			cli.StringFlag{
+				Name:  "profile, p",
+				Usage: "Shows only contexts whose aws profile matches the glob pattern.",
+			},
+			cli.StringFlag{
+				Name:  "cluster, c",
+				Usage: "Shows only contexts whose cluster matches the glob pattern.",
+			},
+			cli.StringFlag{
+				Name:  "user, u",
+				Usage: "Shows only contexts whose user matches the glob pattern.",
+			},
+			cli.StringFlag{
+				Name:  "namespace, n",
+				Usage: "Shows only contexts whose namespace matches the glob pattern.",
+			},
+			selectorFlag,
+			cli.BoolFlag{
+				Name:  "show-labels",
+				Usage: "Adds the labels of the contexts to the table.",
+			},
+			cli.StringFlag{
+				Name:  "sort",
+				Value: eksdefault.SortByName,
+				Usage: "Sorts the contexts by 'name', 'profile', 'cluster' or 'last-used'.",
+			},
+			cli.BoolFlag{
+				Name:  "current",
+				Usage: "Shows only the current-context.",
+			},
+			cli.BoolFlag{
+				Name:  "unbound",
+				Usage: "Shows only contexts without an aws profile.",
+			},
+			cli.BoolFlag{
+				Name:  "dangling",
+				Usage: "Shows only contexts referencing a cluster missing in the kube config.",
+			},
+		},
 		Usage: "Returns all available contexts from the kube config file.",
 		Action: func(c *cli.Context) error {
-			tbl := [][]string{}
-			if !c.Bool("short") {
-				curr := file.CurrentContext
-				for idx, c := range file.Contexts {
-					p := c.AWSprofile
-					cc := ""
-					if c.Name == curr {
-						cc = "*"
-					}
-					row := []string{
-						fmt.Sprintf("%d", idx),
-						cc,
-						c.Name,
-						p,
-						c.Context.Cluster,
-						c.Context.User,
-						c.Context.Namespace,
-					}
-					tbl = append(tbl, row)
+			contexts, err := file.Query(eksdefault.ContextQuery{
+				Name:      c.String("name"),
+				NameRegex: c.String("regex"),
+				Profile:   c.String("profile"),
+				Cluster:   c.String("cluster"),
+				User:      c.String("user"),
+				Namespace: c.String("namespace"),
+				Selector:  c.String("selector"),
+				Current:   c.Bool("current"),
+				Unbound:   c.Bool("unbound"),
+				Dangling:  c.Bool("dangling"),
+				SortBy:    c.String("sort"),
+			})
+			if err != nil {
+				return err
+			}
+			switch c.String("output") {
+			case "short":
+			case "table":
+				if !c.Bool("short") {
+					return printContexts(file, contexts, c.String("columns"), c.Bool("show-labels"))
 				}
-				return printTabbed(
-					[]string{
-						"ID",
-						"CURRENT",
-						"KUBE CONTEXT",
-						"AWS PROFILE",
-						"CLUSTER",
-						"USER",
-						"NAMESPACE"},
-					tbl,
-				)
+			default:
+				return fmt.Errorf("unknown output '%s'; use 'table' or 'short'", c.String("output"))
 			}
-			for _, c := range file.GetContextNames() {
-				output += fmt.Sprintf("%v\n", c)
+			for _, c := range contexts {
+				output += fmt.Sprintf("%v\n", c.Name)
 			}
 			return nil
 		},
 	}
 }
 
-func runMain(args []string) (string, error) {
-	output = ""
-	file, err := eksdefault.GetConfigFile()
+// withConfigFile reads the kube config into file right before the action or the completion
+// of the command runs. Commands, which do not need the parsed kube config, skip this wrapper.
+func withConfigFile(cmd cli.Command, file *eksdefault.KubeConfig) cli.Command {
+	action := cmd.Action.(func(*cli.Context) error)
+	cmd.Action = func(c *cli.Context) error {
+		if err := loadConfigFile(file); err != nil {
+			return err
+		}
+		file.ConfirmProtected = confirmProtected(c, file)
+		file.DryRun = c.GlobalBool("dry-run")
+		if err := action(c); err != nil {
+			return err
+		}
+		printChanges(file)
+		return nil
+	}
+	if complete := cmd.BashComplete; complete != nil {
+		cmd.BashComplete = func(c *cli.Context) {
+			if loadConfigFile(file) == nil {
+				complete(c)
+			}
+		}
+	}
+	return cmd
+}
+
+// loadConfigFile reads the kube config into file. While a pin is active, the commands work on
+// the kube config the pinned context was taken from instead of the pinned copy.
+func loadConfigFile(file *eksdefault.KubeConfig) error {
+	loaded, err := eksdefault.GetUnpinnedConfigFile(options...)
 	if err != nil {
-		return output, err
+		return err
 	}
+	*file = *loaded
+	file.Caller = eksdefault.CallerCLI
+	return nil
+}
+
+// toggleContext supports 'eksdefault -' as shortcut for 'eksdefault set -' and shows the
+// help for everything else.
+func toggleContext(file *eksdefault.KubeConfig) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if c.Args().First() != "-" {
+			return cli.ShowAppHelp(c)
+		}
+		if err := loadConfigFile(file); err != nil {
+			return err
+		}
+		file.ConfirmProtected = confirmProtected(c, file)
+		file.DryRun = c.GlobalBool("dry-run")
+		if err := file.SetPreviousContext(); err != nil {
+			return err
+		}
+		printChanges(file)
+		return nil
+	}
+}
+
+// printChanges prints the diffs of the files, which would be changed in dry-run mode, instead
+// of the usual output.
+func printChanges(file *eksdefault.KubeConfig) {
+	if !file.DryRun || len(file.Changes) < 1 {
+		return
+	}
+	output = ""
+	for _, c := range file.Changes {
+		output += c.Diff()
+	}
+}
+
+// setOptions passes the global flags locating the files to the library and keeps them for
+// the processes started by eksdefault.
+func setOptions(c *cli.Context) {
+	options, globalArgs, credentialsFile = nil, nil, ""
+	if p := c.GlobalString("kubeconfig"); len(p) > 0 {
+		options = append(options, eksdefault.WithPath(p))
+		globalArgs = append(globalArgs, "--kubeconfig", p)
+	}
+	if p := c.GlobalString("aws-credentials-file"); len(p) > 0 {
+		options = append(options, eksdefault.WithCredentialsPath(p))
+		globalArgs = append(globalArgs, "--aws-credentials-file", p)
+		credentialsFile = p
+	}
+	if p := c.GlobalString("config"); len(p) > 0 {
+		options = append(options, eksdefault.WithConfigPath(p))
+		globalArgs = append(globalArgs, "--config", p)
+	}
+}
+
+func runMain(args []string) (string, error) {
+	output = ""
+	rawArgs = args
+	restore := func() {}
+	file := new(eksdefault.KubeConfig)
+	defer func() {
+		restore()
+		file.Close()
+	}()
 	app := cli.NewApp()
+	app.EnableBashCompletion = true
+	app.BashComplete = completeCommands
+	app.Action = toggleContext(file)
+	app.Flags = []cli.Flag{
+		yesFlag,
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Prints the changes of the kube config and the AWS credentials file as unified diff instead of writing them.",
+		},
+		cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "Path of the kube config; default is KUBECONFIG or ~/.kube/config.",
+		},
+		cli.StringFlag{
+			Name:  "aws-credentials-file",
+			Usage: "Path of the AWS credentials file; default is AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials.",
+		},
+		cli.StringFlag{
+			Name:  "config",
+			Usage: "Path of the config file of eksdefault; default is EKSDEFAULT_CONFIG or " + eksdefault.ConfigPath() + ".",
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		setOptions(c)
+		var err error
+		if restore, err = applyConfig(); err != nil {
+			c.App.Writer = ioutil.Discard // no help for a broken config file
+			return err
+		}
+		if !c.GlobalBool("dry-run") {
+			revertExpired()
+		}
+		return nil
+	}
 	app.Commands = []cli.Command{
-		*getCurrentContext(file),
-		*copyContext(file),
-		*addContext(file),
-		*unsetDefaultContext(file),
-		*useNamespace(file),
-		*useProfile(file),
-		*getContexts(file),
-		*setDefaultContext(file),
+		withConfigFile(*getCurrentContext(file), file),
+		withConfigFile(*copyContext(file), file),
+		withConfigFile(*addContext(file), file),
+		withConfigFile(*unsetDefaultContext(file), file),
+		withConfigFile(*useNamespace(file), file),
+		withConfigFile(*useProfile(file), file),
+		withConfigFile(*getContexts(file), file),
+		withConfigFile(*setDefaultContext(file), file),
+		withConfigFile(*setContextBack(file), file),
+		withConfigFile(*pickContext(file), file),
+		withConfigFile(*protectContext(file, true), file),
+		withConfigFile(*protectContext(file, false), file),
+		withConfigFile(*getStatus(file), file),
+		withConfigFile(*editContext(file), file),
+		withConfigFile(*extractContext(file), file),
+		withConfigFile(*mergeConfig(file), file),
+		withConfigFile(*pruneContexts(file), file),
+		withConfigFile(*pingContexts(file), file),
+		withConfigFile(*labelContexts(file), file),
+		withConfigFile(*eachContext(file), file),
+		withConfigFile(*execPinned(file), file),
+		withConfigFile(*envPinned(file), file),
+		*manageClusters(file),
+		*manageUsers(file),
+		*watchExpiry(),
+		*getHistory(),
+		*completion(),
+		*prompt(),
+		*shellHook(),
+		*manageConfig(),
+	}
+	for idx, cmd := range app.Commands {
+		app.Commands[idx] = withFlagCompletion(cmd)
 	}
 	return output, app.Run(args)
 }
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/peterbueschel/awsdefault"
+	"github.com/peterbueschel/eksdefault"
 )
 
 var (
@@ -22,10 +31,18 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 		os.Exit(1)
 	}
+	stateDir, err := ioutil.TempDir("", "eksdefault-state")
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+	os.Setenv("EKSDEFAULT_STATE_DIR", stateDir)
+	os.Setenv("EKSDEFAULT_CONFIG_DIR", stateDir)
 	// run
 	code := m.Run()
 
 	// teardown
+	os.RemoveAll(stateDir)
 	os.Setenv("HOME", "testdata")
 	awsfile, err := awsdefault.GetCredentialsFile()
 	if err != nil {
@@ -125,6 +142,152 @@ func Test_runMain(t *testing.T) {
 			envVar:  "KUBECONFIG",
 			envVal:  "testdata/.kube/config",
 		},
+		{
+			name:    "2getContexts - positive - filter by profile",
+			args:    args{[]string{self, "ls", "-s", "-p", "live"}},
+			want:    "cntxA\ncntxB\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "3getContexts - positive - no match prints only the header",
+			args:    args{[]string{self, "ls", "--name", "cntx[C]*", "--unbound"}},
+			want:    "ID       CURRENT       KUBE CONTEXT       AWS PROFILE       CLUSTER       USER       NAMESPACE\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "4getContexts - positive - unbound contexts keep their ID",
+			args:    args{[]string{self, "ls", "--unbound"}},
+			want:    "ID       CURRENT       KUBE CONTEXT       AWS PROFILE       CLUSTER        USER           NAMESPACE\n3                      minikube                             minikube       minikube       \n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "5getContexts - positive - sorted by profile and regex",
+			args:    args{[]string{self, "ls", "-s", "--sort", "profile", "-r", "^cntx"}},
+			want:    "cntxC\ncntxA\ncntxB\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "6getContexts - positive - current and dangling",
+			args:    args{[]string{self, "ls", "-s", "--current", "--dangling"}},
+			want:    "cntxB\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "7getContexts - negative - unknown sort order",
+			args:    args{[]string{self, "ls", "--sort", "xxxx"}},
+			want:    "",
+			wantErr: true,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		// Completion
+		{
+			name:    "0completion - positive - contexts for set",
+			args:    args{[]string{self, "set", "--generate-bash-completion"}},
+			want:    "cntxA\tID 0, aws profile 'live'\ncntxB\tID 1, aws profile 'live'\ncntxC\tID 2, aws profile 'dev'\nminikube\tID 3, aws profile ''\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "1completion - positive - used namespaces for namespace",
+			args:    args{[]string{self, "ns", "--generate-bash-completion"}},
+			want:    "aaaaa\nbbbbb\nccccc\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "2completion - positive - contexts as second argument of namespace",
+			args:    args{[]string{self, "ns", "aaaaa", "--generate-bash-completion"}},
+			want:    "cntxA\tID 0, aws profile 'live'\ncntxB\tID 1, aws profile 'live'\ncntxC\tID 2, aws profile 'dev'\nminikube\tID 3, aws profile ''\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "3completion - positive - aws profiles for profile",
+			args:    args{[]string{self, "profile", "--generate-bash-completion"}},
+			want:    "anotherprofile\ndev\nlive\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "4completion - positive - aws profiles for the profile flag",
+			args:    args{[]string{self, "new", "x", "-p", "--generate-bash-completion"}},
+			want:    "anotherprofile\ndev\nlive\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "5completion - positive - print bash script",
+			args:    args{[]string{self, "completion", "bash"}},
+			want:    bashCompletion,
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "6completion - negative - unknown shell",
+			args:    args{[]string{self, "completion", "tcsh"}},
+			want:    "",
+			wantErr: true,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		// Prompt
+		{
+			name:    "0prompt - positive - default format",
+			args:    args{[]string{self, "prompt"}},
+			want:    "⎈ cntxB/bbbbb (live)\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "1prompt - positive - custom format with colors for bash",
+			args:    args{[]string{self, "prompt", "-f", "{{.Cluster}}", "--colors", "dev=green,live=red+bold", "--shell", "bash"}},
+			want:    "\\[\033[31;1m\\]clstrB\\[\033[0m\\]\n",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "2prompt - positive - no current-context prints nothing",
+			args:    args{[]string{self, "prompt"}},
+			want:    "",
+			wantErr: false,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/noCurrentContext",
+		},
+		{
+			name:    "3prompt - negative - unknown color",
+			args:    args{[]string{self, "prompt", "--colors", "live=pink"}},
+			want:    "",
+			wantErr: true,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/config",
+		},
+		{
+			name:    "4prompt - negative - no file found",
+			args:    args{[]string{self, "prompt"}},
+			want:    "",
+			wantErr: true,
+			envVar:  "KUBECONFIG",
+			envVal:  "testdata/.kube/somewhere",
+		},
 		// Add
 		{
 			name:    "0addContext - negative - missing argument",
@@ -430,3 +593,1166 @@ func Test_runMain(t *testing.T) {
 		})
 	}
 }
+
+func Test_runMain_previousContext(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer func() {
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	tests := []struct {
+		name               string
+		args               []string
+		wantErr            bool
+		wantCurrentContext string
+	}{
+		{
+			name:               "0previous - positive - switch to cntxC",
+			args:               []string{self, "set", "cntxC"},
+			wantCurrentContext: "cntxC\n",
+		},
+		{
+			name:               "1previous - positive - toggle via 'eksdefault -'",
+			args:               []string{self, "-"},
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "2previous - positive - toggle via 'eksdefault set -'",
+			args:               []string{self, "set", "-"},
+			wantCurrentContext: "cntxC\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := runMain(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("runMain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got, err := runMain([]string{self, "is"})
+			if err != nil {
+				t.Errorf("runMain() error get current-context = %v", err)
+			}
+			if got != tt.wantCurrentContext {
+				t.Errorf("runMain() got = %+v, wantCurrentContext = %+v", got, tt.wantCurrentContext)
+			}
+		})
+	}
+}
+
+func Test_runMain_history(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer func() {
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if _, err := runMain([]string{self, "set", "cntxA"}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	got, err := runMain([]string{self, "history", "-m", "1"})
+	if err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("runMain() history =\n%s, want header and one entry", got)
+	}
+	if f := strings.Fields(lines[1]); len(f) != 7 || f[0] != "1" || f[3] != "cntxB" || f[4] != "cntxA" || f[5] != "live" || f[6] != "cli" {
+		t.Errorf("runMain() history entry = %v, want 1 <time> cntxB cntxA live cli", f)
+	}
+	if _, err := runMain([]string{self, "back", "x"}); err == nil {
+		t.Errorf("runMain() back with no number wants an error")
+	}
+	if _, err := runMain([]string{self, "back"}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	if got, _ := runMain([]string{self, "is"}); got != "cntxB\n" {
+		t.Errorf("runMain() got = %+v, wantCurrentContext = cntxB", got)
+	}
+}
+
+func Test_runMain_protected(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	oldStdin, oldStderr, oldInteractive := stdin, stderr, interactive
+	defer func() {
+		stdin, stderr, interactive = oldStdin, oldStderr, oldInteractive
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	stderr = ioutil.Discard
+	if _, err := runMain([]string{self, "protect", "cntxC", "0"}); err != nil {
+		t.Fatalf("runMain() error protect = %v", err)
+	}
+	tests := []struct {
+		name               string
+		args               []string
+		interactive        bool
+		input              string
+		wantErr            bool
+		wantCurrentContext string
+	}{
+		{
+			name:               "0protected - negative - no terminal and no --yes",
+			args:               []string{self, "set", "cntxC"},
+			wantErr:            true,
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "1protected - negative - wrong name typed in",
+			args:               []string{self, "set", "cntxC"},
+			interactive:        true,
+			input:              "cntxA\n",
+			wantErr:            true,
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "2protected - positive - name typed in",
+			args:               []string{self, "set", "cntxC"},
+			interactive:        true,
+			input:              "cntxC\n",
+			wantCurrentContext: "cntxC\n",
+		},
+		{
+			name:               "3protected - positive - unprotected context does not ask",
+			args:               []string{self, "set", "cntxB"},
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "4protected - positive - set --yes",
+			args:               []string{self, "set", "--yes", "cntxA"},
+			wantCurrentContext: "cntxA\n",
+		},
+		{
+			name:               "5protected - positive - global --yes with toggle",
+			args:               []string{self, "-y", "-"},
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "6protected - positive - unprotect cntxC by ID",
+			args:               []string{self, "unprotect", "2"},
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "7protected - positive - switch to unprotected cntxC",
+			args:               []string{self, "set", "cntxC"},
+			wantCurrentContext: "cntxC\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isInteractive := tt.interactive
+			interactive = func() bool { return isInteractive }
+			stdin = strings.NewReader(tt.input)
+			if _, err := runMain(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("runMain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got, err := runMain([]string{self, "is"})
+			if err != nil {
+				t.Errorf("runMain() error get current-context = %v", err)
+			}
+			if got != tt.wantCurrentContext {
+				t.Errorf("runMain() got = %+v, wantCurrentContext = %+v", got, tt.wantCurrentContext)
+			}
+		})
+	}
+}
+
+func Test_runMain_expiry(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	oldStderr := stderr
+	defer func() {
+		stderr = oldStderr
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	stderr = ioutil.Discard
+	tests := []struct {
+		name    string
+		args    []string
+		wait    time.Duration
+		wantErr bool
+		wantIs  string
+	}{
+		{
+			name:   "0expiry - positive - switch for 30 minutes",
+			args:   []string{self, "set", "--for", "30m", "cntxC"},
+			wantIs: "cntxC (expires in 30m0s, then 'cntxB')\n",
+		},
+		{
+			name:   "1expiry - positive - a manual switch drops the expiry",
+			args:   []string{self, "set", "cntxB"},
+			wantIs: "cntxB\n",
+		},
+		{
+			name:   "2expiry - positive - the next invocation reverts to the safe context",
+			args:   []string{self, "set", "--for", "1ms", "--revert-to", "cntxA", "cntxC"},
+			wait:   10 * time.Millisecond,
+			wantIs: "cntxA\n",
+		},
+		{
+			name:    "3expiry - negative - '--for' with '-'",
+			args:    []string{self, "set", "--for", "1m", "-"},
+			wantErr: true,
+			wantIs:  "cntxA\n",
+		},
+		{
+			name:    "4expiry - negative - unknown safe context",
+			args:    []string{self, "set", "--for", "1m", "--revert-to", "xxx", "cntxC"},
+			wantErr: true,
+			wantIs:  "cntxA\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := runMain(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("runMain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			time.Sleep(tt.wait)
+			got, err := runMain([]string{self, "is"})
+			if err != nil {
+				t.Errorf("runMain() error get current-context = %v", err)
+			}
+			if got != tt.wantIs && strings.Replace(got, "29m59s", "30m0s", 1) != tt.wantIs {
+				t.Errorf("runMain() got = %+v, wantIs = %+v", got, tt.wantIs)
+			}
+		})
+	}
+	t.Run("5expiry - positive - status and watch", func(t *testing.T) {
+		if _, err := runMain([]string{self, "set", "--for", "50ms", "cntxC"}); err != nil {
+			t.Fatalf("runMain() error = %v", err)
+		}
+		got, err := runMain([]string{self, "status"})
+		if err != nil || !strings.Contains(got, "context:        cntxC\n") || !strings.Contains(got, "expiry:         expires in ") {
+			t.Errorf("runMain() status = %q, %v", got, err)
+		}
+		got, err = runMain([]string{self, "watch", "-i", "10ms"})
+		if err != nil || got != "The context 'cntxC' expired; switched back to 'cntxA'.\n" {
+			t.Errorf("runMain() watch = %q, %v", got, err)
+		}
+		if got, _ = runMain([]string{self, "is"}); got != "cntxA\n" {
+			t.Errorf("runMain() got = %+v, want cntxA", got)
+		}
+		// nothing to watch
+		if got, err = runMain([]string{self, "watch"}); err != nil || got != "" {
+			t.Errorf("runMain() watch = %q, %v, want no output", got, err)
+		}
+	})
+	t.Run("6expiry - positive - no revert in dry-run mode", func(t *testing.T) {
+		if _, err := runMain([]string{self, "set", "--for", "1ms", "cntxC"}); err != nil {
+			t.Fatalf("runMain() error = %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if got, err := runMain([]string{self, "--dry-run", "is"}); err != nil || !strings.HasPrefix(got, "cntxC ") {
+			t.Errorf("runMain() dry-run = %q, %v, want the expired context kept", got, err)
+		}
+		if _, err := runMain([]string{self, "--dry-run", "watch"}); err == nil {
+			t.Errorf("runMain() watch in dry-run mode, want error")
+		}
+		if got, _ := runMain([]string{self, "is"}); got != "cntxA\n" {
+			t.Errorf("runMain() got = %+v, want cntxA", got)
+		}
+	})
+}
+
+func Test_runMain_dryRun(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer os.Unsetenv("KUBECONFIG")
+	kubeBefore, err := ioutil.ReadFile("testdata/.kube/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credsBefore, err := ioutil.ReadFile("testdata/.aws/credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "0dryrun - positive - set",
+			args: []string{self, "--dry-run", "set", "cntxC"},
+			want: []string{"+current-context: cntxC\n", "--- testdata/.aws/credentials\n", "+aws_access_key_id"},
+		},
+		{
+			name: "1dryrun - positive - profile",
+			args: []string{self, "--dry-run", "profile", "dev", "cntxA"},
+			want: []string{"--- testdata/.kube/config\n", "+  aws-profile: dev\n"},
+		},
+		{
+			name: "2dryrun - positive - namespace",
+			args: []string{self, "--dry-run", "namespace", "kube-system"},
+			want: []string{"+    namespace: kube-system\n"},
+		},
+		{
+			name: "3dryrun - positive - new",
+			args: []string{self, "--dry-run", "new", "cntxD", "-c", "clstrD"},
+			want: []string{"+- name: cntxD\n", "+    cluster: clstrD\n"},
+		},
+		{
+			name: "4dryrun - positive - copy",
+			args: []string{self, "--dry-run", "copy", "cntxE"},
+			want: []string{"+- name: cntxE\n"},
+		},
+		{
+			name: "5dryrun - positive - unset",
+			args: []string{self, "--dry-run", "unset"},
+			want: []string{"-current-context: cntxB\n", "+current-context: \"\"\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runMain(tt.args)
+			if err != nil {
+				t.Errorf("runMain() error = %v", err)
+				return
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("runMain() got = %s, want it to contain %q", got, w)
+				}
+			}
+			if after, _ := ioutil.ReadFile("testdata/.kube/config"); string(after) != string(kubeBefore) {
+				t.Errorf("runMain() changed the kube config in dry-run mode")
+			}
+			if after, _ := ioutil.ReadFile("testdata/.aws/credentials"); string(after) != string(credsBefore) {
+				t.Errorf("runMain() changed the credentials file in dry-run mode")
+			}
+		})
+	}
+}
+
+func Test_runMain_edit(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer func() {
+		os.Unsetenv("KUBECONFIG")
+		os.Unsetenv("EDITOR")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	tests := []struct {
+		name    string
+		args    []string
+		editor  string
+		wantErr bool
+		want    string
+	}{
+		{
+			name: "0edit - positive - flags by ID",
+			args: []string{self, "edit", "0", "-u", "userB", "-c", "ava", "-n", "x", "-p", "dev"},
+			want: "0 cntxA dev ava userB x\n",
+		},
+		{
+			name: "1edit - positive - editor",
+			args: []string{self, "edit", "-e", "cntxC"},
+			// renames the context and changes its namespace
+			editor: "perl -pi -e s/cntxC/cntxCC/;s/ccccc/edited/",
+			want:   "2 cntxCC dev clstrC userC edited\n",
+		},
+		{
+			name:    "2edit - negative - unknown cluster",
+			args:    []string{self, "edit", "cntxB", "-c", "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "3edit - negative - unknown user",
+			args:    []string{self, "edit", "cntxB", "-u", "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "4edit - negative - nothing to change",
+			args:    []string{self, "edit", "cntxB"},
+			wantErr: true,
+		},
+		{
+			name:    "5edit - negative - invalid YAML",
+			args:    []string{self, "edit", "-e", "cntxB"},
+			editor:  "perl -pi -e s/namespace/unknown-field/",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("EDITOR", tt.editor)
+			if _, err := runMain(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("runMain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			out, err := runMain([]string{self, "ls"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			for _, line := range strings.Split(out, "\n") {
+				got += strings.Join(strings.Fields(line), " ") + "\n"
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("runMain() got = %s, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runMain_clustersAndUsers(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer func() {
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dir, err := ioutil.TempDir("", "eksdefault-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := filepath.Join(dir, "ca.crt")
+	if err := ioutil.WriteFile(ca, []byte("-----BEGIN CERTIFICATE-----\nY2E=\n-----END CERTIFICATE-----\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		list    []string
+		want    string
+	}{
+		{
+			name: "0cluster - positive - add with embedded CA",
+			args: []string{self, "cluster", "add", "clstrA", "-s", "https://a", "--ca", ca, "--embed"},
+			list: []string{self, "cluster", "ls"},
+			want: "clstrA https://a embedded cntxA\n",
+		},
+		{
+			name:    "0cluster - negative - add with an invalid CA",
+			args:    []string{self, "cluster", "add", "clstrX", "-s", "https://x", "--ca", "testdata/.kube/config"},
+			wantErr: true,
+		},
+		{
+			name: "0cluster - positive - the cluster with the invalid CA was not added",
+			args: []string{self, "cluster", "add", "clstrX", "-s", "https://x"},
+			list: []string{self, "cluster", "ls"},
+			want: "clstrX https://x -\n",
+		},
+		{
+			name: "1cluster - positive - set-server and set-ca",
+			args: []string{self, "cluster", "set-server", "minikube", "https://127.0.0.1:8443"},
+			list: []string{self, "cluster", "ls"},
+			want: "minikube https://127.0.0.1:8443 minikube\n",
+		},
+		{
+			name: "2cluster - positive - set-ca as file",
+			args: []string{self, "cluster", "set-ca", "minikube", ca},
+			list: []string{self, "cluster", "ls"},
+			want: "minikube https://127.0.0.1:8443 " + ca + " minikube\n",
+		},
+		{
+			name:    "3cluster - negative - remove a used cluster",
+			args:    []string{self, "cluster", "rm", "minikube"},
+			wantErr: true,
+		},
+		{
+			name: "4cluster - positive - remove an unused cluster",
+			args: []string{self, "cluster", "rm", "aord"},
+			list: []string{self, "cluster", "ls"},
+			want: "CLUSTER SERVER CA CONTEXTS\nava -\navad -\nclstrA",
+		},
+		{
+			name: "5cluster - positive - remove a used cluster with force",
+			args: []string{self, "cluster", "rm", "-f", "minikube"},
+			list: []string{self, "ls", "--dangling", "-s"},
+			want: "cntxB\ncntxC\nminikube\n",
+		},
+		{
+			name: "6user - positive - add with credential plugin",
+			args: []string{self, "user", "add", "-e", "AWS_PROFILE=dev", "userD", "--", "aws", "eks", "get-token", "--cluster-name", "d"},
+			list: []string{self, "user", "ls"},
+			want: "userD aws eks get-token --cluster-name d -\n",
+		},
+		{
+			name: "7user - positive - set-exec",
+			args: []string{self, "user", "set-exec", "userB", "--", "aws-iam-authenticator", "token", "-i", "b"},
+			list: []string{self, "user", "ls"},
+			want: "userB aws-iam-authenticator token -i b cntxB\n",
+		},
+		{
+			name:    "8user - negative - set-exec without command",
+			args:    []string{self, "user", "set-exec", "userB"},
+			wantErr: true,
+		},
+		{
+			name:    "9user - negative - remove a used user",
+			args:    []string{self, "user", "rm", "userA"},
+			wantErr: true,
+		},
+		{
+			name: "10user - positive - remove an unused user",
+			args: []string{self, "user", "rm", "userD"},
+			list: []string{self, "user", "ls"},
+			want: "USER AUTH CONTEXTS\nminikube minikube\nuserA cntxA\nuserB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := runMain(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("runMain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			out, err := runMain(tt.list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			for _, line := range strings.Split(out, "\n") {
+				got += strings.Join(strings.Fields(line), " ") + "\n"
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("runMain() got = %s, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runMain_extract(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer os.Unsetenv("KUBECONFIG")
+	dir, err := ioutil.TempDir("", "eksdefault-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	got, err := runMain([]string{self, "extract", "3"})
+	if err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	for _, want := range []string{"current-context: minikube\n", "- name: minikube\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("runMain() got = %s, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "cntxB") {
+		t.Errorf("runMain() got = %s, want only the context minikube", got)
+	}
+	out := filepath.Join(dir, "config")
+	if _, err := runMain([]string{self, "extract", "minikube", "-o", out}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	if content, err := ioutil.ReadFile(out); err != nil || string(content) != got {
+		t.Errorf("runMain() wrote %s, %v, want %s", content, err, got)
+	}
+	// cntxA references a cluster missing in the kube config
+	if _, err := runMain([]string{self, "extract", "cntxA"}); err == nil {
+		t.Errorf("runMain() extract of a dangling context, want error")
+	}
+}
+
+func Test_runMain_merge(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer os.Unsetenv("KUBECONFIG")
+	defer ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644)
+	dir, err := ioutil.TempDir("", "eksdefault-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	other := filepath.Join(dir, "config")
+	content := "clusters:\n- cluster:\n    server: https://new\n  name: newc\n" +
+		"contexts:\n- context:\n    cluster: newc\n    user: minikube\n  name: cntxC\n" +
+		"- context:\n    cluster: newc\n    user: minikube\n  name: new\n"
+	if err := ioutil.WriteFile(other, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runMain([]string{self, "merge", other, "-s", "unknown"}); err == nil {
+		t.Errorf("runMain() merge with unknown strategy, want error")
+	}
+	got, err := runMain([]string{self, "merge", "-s", "rename", "-p", "dev", other})
+	if err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	want := [][]string{
+		{"KIND", "NAME", "ACTION"},
+		{"cluster", "newc", "added"},
+		{"context", "cntxC", "renamed", "to", "cntxC-merged"},
+		{"context", "new", "added"},
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("runMain() got = %s, want %v", got, want)
+	}
+	for i, l := range lines {
+		if strings.Join(strings.Fields(l), " ") != strings.Join(want[i], " ") {
+			t.Errorf("runMain() line %d = %q, want %v", i, l, want[i])
+		}
+	}
+	got, err = runMain([]string{self, "ls", "--name", "cntxC-merged"})
+	if err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	for _, want := range []string{"newc", "dev"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("runMain() got = %s, want it to contain %q", got, want)
+		}
+	}
+}
+
+func Test_runMain_prune(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	oldStdin, oldStderr, oldInteractive := stdin, stderr, interactive
+	defer func() {
+		stdin, stderr, interactive = oldStdin, oldStderr, oldInteractive
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	stderr = ioutil.Discard
+	interactive = func() bool { return false }
+
+	if _, err := runMain([]string{self, "prune", "-m", "unknown"}); err == nil {
+		t.Errorf("runMain() prune with unknown method, want error")
+	}
+	if _, err := runMain([]string{self, "prune", "-m", "reach"}); err == nil {
+		t.Errorf("runMain() prune without confirmation, want error")
+	}
+	interactive = func() bool { return true }
+	stdin = strings.NewReader("n\n")
+	if _, err := runMain([]string{self, "prune", "-m", "reach"}); err == nil {
+		t.Errorf("runMain() prune declined, want error")
+	}
+	if content, _ := ioutil.ReadFile("testdata/.kube/config"); string(content) != string(testFileContent) {
+		t.Errorf("runMain() prune changed the kube config without confirmation")
+	}
+	stdin = strings.NewReader("y\n")
+	got, err := runMain([]string{self, "prune", "-m", "reach"})
+	if err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	// cntxA, cntxB and cntxC use missing clusters, minikube has no server
+	want := []string{
+		"KIND REMOVED",
+		"context cntxA", "context cntxB", "context cntxC", "context minikube",
+		"cluster minikube",
+		"user userA", "user userB", "user userC", "user minikube",
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("runMain() got = %s, want %v", got, want)
+	}
+	for i, l := range lines {
+		if strings.Join(strings.Fields(l), " ") != want[i] {
+			t.Errorf("runMain() line %d = %q, want %q", i, l, want[i])
+		}
+	}
+	if got, err = runMain([]string{self, "prune", "-m", "reach"}); err != nil || got != "no stale contexts found" {
+		t.Errorf("runMain() = %q, %v, want no stale contexts", got, err)
+	}
+}
+
+// setupAPIServer starts a fake Kubernetes API server with the namespaces default and payments
+// and points KUBECONFIG to a kube config with the contexts 'up' and 'denied' using it.
+func setupAPIServer(t *testing.T) func() {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			fmt.Fprint(w, `{"gitVersion":"v1.30.0"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/apis/authentication.k8s.io/v1/selfsubjectreviews":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"status":{"userInfo":{"username":"admin"}}}`)
+		case "/api/v1/namespaces":
+			fmt.Fprint(w, `{"items":[{"metadata":{"name":"payments"}},{"metadata":{"name":"default"}}]}`)
+		case "/api/v1/namespaces/default", "/api/v1/namespaces/payments":
+			fmt.Fprint(w, `{"metadata":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	dir, err := ioutil.TempDir("", "eksdefault-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	config := filepath.Join(dir, "config")
+	content := fmt.Sprintf("clusters:\n- cluster:\n    certificate-authority-data: %s\n    server: %s\n  name: c\n"+
+		"contexts:\n- context: {cluster: c, user: good}\n  name: up\n- context: {cluster: c, user: bad}\n  name: denied\n"+
+		"current-context: up\nusers:\n- name: good\n  user: {token: good}\n- name: bad\n  user: {token: bad}\n", ca, server.URL)
+	if err := ioutil.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", config)
+	return func() {
+		os.Unsetenv("KUBECONFIG")
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func Test_runMain_ping(t *testing.T) {
+	defer setupAPIServer(t)()
+
+	tests := []struct {
+		args []string
+		want [][]string
+	}{
+		{
+			args: []string{self, "ping"},
+			want: [][]string{{"up", "yes", "yes", "v1.30.0"}},
+		},
+		{
+			args: []string{self, "ping", "--all"},
+			want: [][]string{{"denied", "yes", "no", "v1.30.0"}, {"up", "yes", "yes", "v1.30.0"}},
+		},
+		{
+			args: []string{self, "ping", "1", "0"},
+			want: [][]string{{"up", "yes", "yes", "v1.30.0"}, {"denied", "yes", "no", "v1.30.0"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := runMain(tt.args)
+		if err != nil {
+			t.Fatalf("runMain(%v) error = %v", tt.args, err)
+		}
+		lines := strings.Split(strings.TrimSpace(got), "\n")
+		if len(lines) != len(tt.want)+1 || strings.Join(strings.Fields(lines[0]), " ") != "CONTEXT REACHABLE AUTH VERSION LATENCY ERROR" {
+			t.Fatalf("runMain(%v) got = %s, want %d rows", tt.args, got, len(tt.want))
+		}
+		for i, want := range tt.want {
+			if fields := strings.Fields(lines[i+1]); strings.Join(fields[:4], " ") != strings.Join(want, " ") {
+				t.Errorf("runMain(%v) row %d = %q, want %v", tt.args, i, lines[i+1], want)
+			}
+		}
+	}
+}
+
+func Test_runMain_namespaceList(t *testing.T) {
+	defer setupAPIServer(t)()
+
+	got, err := runMain([]string{self, "ns", "--list"})
+	if err != nil || got != "default\npayments\n" {
+		t.Errorf("runMain() = %q, %v, want the namespaces default and payments", got, err)
+	}
+	if _, err := runMain([]string{self, "ns", "--list", "denied"}); err == nil {
+		t.Errorf("runMain() list with invalid credentials, want error")
+	}
+	if got, err = runMain([]string{self, "ns", "--generate-bash-completion"}); err != nil || got != "default\npayments\n" {
+		t.Errorf("runMain() completion = %q, %v, want the cached namespaces", got, err)
+	}
+	if _, err := runMain([]string{self, "ns", "--validate", "paymnets"}); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("runMain() error = %v, want the namespace to not exist", err)
+	}
+	if _, err := runMain([]string{self, "ns", "--validate", "payments"}); err != nil {
+		t.Errorf("runMain() error = %v", err)
+	}
+	if got, err = runMain([]string{self, "status"}); err != nil || !strings.Contains(got, "payments") {
+		t.Errorf("runMain() status = %q, %v, want namespace payments", got, err)
+	}
+	// without validation any namespace is accepted
+	if _, err := runMain([]string{self, "ns", "paymnets", "denied"}); err != nil {
+		t.Errorf("runMain() error = %v", err)
+	}
+}
+
+func Test_runMain_namespaceHistory(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	oldStdin, oldStderr, oldInteractive := stdin, stderr, interactive
+	defer func() {
+		stdin, stderr, interactive = oldStdin, oldStderr, oldInteractive
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	os.Remove(filepath.Join(eksdefault.StateDir(), "namespaces.history"))
+	stderr = ioutil.Discard
+	interactive = func() bool { return false }
+
+	if _, err := runMain([]string{self, "ns", "-", "cntxC"}); err != eksdefault.NoPreviousNamespace {
+		t.Errorf("runMain() error = %v, want %v", err, eksdefault.NoPreviousNamespace)
+	}
+	for _, ns := range []string{"one", "two"} {
+		if _, err := runMain([]string{self, "ns", ns}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := runMain([]string{self, "ns", "-"}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	if got, _ := runMain([]string{self, "status"}); !strings.Contains(got, "namespace:      one\n") {
+		t.Errorf("runMain() status = %s, want namespace one", got)
+	}
+	if got, err := runMain([]string{self, "ns", "--recent"}); err != nil || got != "two\nbbbbb\n" {
+		t.Errorf("runMain() = %q, %v, want two and bbbbb", got, err)
+	}
+	interactive = func() bool { return true }
+	stdin = strings.NewReader("1\n")
+	if _, err := runMain([]string{self, "ns", "--recent", "cntxB"}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	if got, _ := runMain([]string{self, "status"}); !strings.Contains(got, "namespace:      bbbbb\n") {
+		t.Errorf("runMain() status = %s, want namespace bbbbb", got)
+	}
+	stdin = strings.NewReader("7\n")
+	if _, err := runMain([]string{self, "ns", "--recent"}); err == nil {
+		t.Errorf("runMain() with an unknown number, want error")
+	}
+}
+
+func Test_runMain_labels(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer func() {
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for _, args := range [][]string{
+		{self, "label", "cntxA", "cntxB", "env=prod", "team=payments"},
+		{self, "label", "2", "env=dev"},
+		{self, "label", "-l", "team=payments", "tier=1"},
+		{self, "label", "cntxB", "team-"},
+	} {
+		if _, err := runMain(args); err != nil {
+			t.Fatalf("runMain(%v) error = %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{self, "label", "env=prod"},
+		{self, "label", "cntxA", "in valid=x"},
+		{self, "set", "-l", "env=prod"},
+		{self, "set", "cntxA", "-l", "env=dev"},
+		{self, "protect", "-l", "env=unknown"},
+		{self, "ls", "-l", "env in (prod"},
+	} {
+		if _, err := runMain(args); err == nil {
+			t.Errorf("runMain(%v), want error", args)
+		}
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{self, "ls", "-s", "-l", "env=prod"}, "cntxA\ncntxB\n"},
+		{[]string{self, "ls", "-s", "--selector", "env,!team"}, "cntxB\ncntxC\n"},
+		{[]string{self, "ls", "-s", "-l", "env notin (prod)"}, "cntxC\nminikube\n"},
+		{[]string{self, "label", "-l", "tier"}, "KUBE CONTEXT LABELS\ncntxA env=prod,team=payments,tier=1\ncntxB env=prod,tier=1\n"},
+		{[]string{self, "label", "minikube"}, "KUBE CONTEXT LABELS\nminikube\n"},
+		{[]string{self, "ls", "--show-labels", "-l", "env=dev"}, "ID CURRENT KUBE CONTEXT AWS PROFILE CLUSTER USER NAMESPACE LABELS\n2 cntxC dev clstrC userC ccccc env=dev\n"},
+	}
+	for _, tt := range tests {
+		got, err := runMain(tt.args)
+		if err != nil {
+			t.Fatalf("runMain(%v) error = %v", tt.args, err)
+		}
+		lines := []string{}
+		for _, l := range strings.Split(got, "\n") {
+			if fields := strings.Fields(l); len(fields) > 0 {
+				lines = append(lines, strings.Join(fields, " ")+"\n")
+			}
+		}
+		if strings.Join(lines, "") != tt.want {
+			t.Errorf("runMain(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+	// single context commands require exactly one match
+	if _, err := runMain([]string{self, "ns", "-l", "env=dev", "other"}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	if got, _ := runMain([]string{self, "ls", "-s", "-n", "other"}); got != "cntxC\n" {
+		t.Errorf("runMain() = %q, want the namespace of cntxC changed", got)
+	}
+	if _, err := runMain([]string{self, "protect", "-l", "env=prod"}); err != nil {
+		t.Fatalf("runMain() error = %v", err)
+	}
+	if got, _ := runMain([]string{self, "status"}); !strings.Contains(got, "protected:      yes") {
+		t.Errorf("runMain() status = %s, want cntxB protected", got)
+	}
+}
+
+func Test_runMain_each(t *testing.T) {
+	defer setupAPIServer(t)()
+	var out, errOut strings.Builder
+	oldStdout, oldStderr := stdout, stderr
+	stdout, stderr = &out, &errOut
+	defer func() { stdout, stderr = oldStdout, oldStderr }()
+
+	script := `grep -q "current-context: $EKSDEFAULT_CONTEXT" "$KUBECONFIG" && echo "$EKSDEFAULT_CONTEXT"; [ "$EKSDEFAULT_CONTEXT" = up ]`
+	_, err := runMain([]string{self, "each", "--all", "--", "sh", "-c", script})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("runMain() error = %v, want the command to fail for one context", err)
+	}
+	if got := out.String(); !strings.Contains(got, "[up] up\n") || !strings.Contains(got, "[denied] denied\n") {
+		t.Errorf("runMain() output = %q, want prefixed lines of both contexts", got)
+	}
+	summary := strings.Split(strings.TrimSpace(errOut.String()), "\n")
+	if len(summary) != 3 || strings.Join(strings.Fields(summary[1])[:2], " ") != "denied 1" || strings.Join(strings.Fields(summary[2])[:2], " ") != "up 0" {
+		t.Errorf("runMain() summary = %q, want the exit codes", errOut.String())
+	}
+
+	out.Reset()
+	if _, err := runMain([]string{self, "each", "-g", "up", "--", "sh", "-c", script}); err != nil {
+		t.Errorf("runMain() error = %v", err)
+	}
+	if got := out.String(); got != "=== up (exit 0)\nup\n" {
+		t.Errorf("runMain() grouped output = %q", got)
+	}
+	if _, err := runMain([]string{self, "each", "up"}); err == nil {
+		t.Errorf("runMain() without command, want error")
+	}
+}
+
+func Test_runMain_pin(t *testing.T) {
+	defer setupAPIServer(t)()
+	config := os.Getenv("KUBECONFIG")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "eksdefault-pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(dir, "service", "deploy")
+	pinPath := filepath.Join(dir, "service", eksdefault.PinFileName)
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(pinPath, []byte("context: denied\nnamespace: payments\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	oldStdout := stdout
+	stdout = &out
+	os.Setenv("HOME", filepath.Join(wd, "testdata"))
+	defer func() {
+		stdout = oldStdout
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+		os.Setenv("HOME", "testdata")
+		for _, name := range []string{eksdefault.UnpinnedKubeConfigEnv, eksdefault.PinEnv} {
+			os.Unsetenv(name)
+		}
+	}()
+	if err := os.Chdir(nested); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := runMain([]string{self, "env"})
+	pinned := filepath.Join(os.Getenv("EKSDEFAULT_STATE_DIR"), "pins", "denied.payments.config")
+	for _, want := range []string{
+		fmt.Sprintf("export KUBECONFIG='%s'\n", pinned),
+		fmt.Sprintf("export %s='%s'\n", eksdefault.UnpinnedKubeConfigEnv, config),
+		fmt.Sprintf("export %s='%s'\n", eksdefault.PinEnv, pinPath),
+		"unset AWS_PROFILE\n",
+	} {
+		if err != nil || !strings.Contains(got, want) {
+			t.Errorf("runMain() env = %q, %v, want %q", got, err, want)
+		}
+	}
+	if got, err := runMain([]string{self, "env", "--shell", "fish", "up"}); err != nil || !strings.Contains(got, "set -gx EKSDEFAULT_CONTEXT 'up';\n") {
+		t.Errorf("runMain() env for fish = %q, %v", got, err)
+	}
+	if got, err := runMain([]string{self, "status"}); err != nil || !strings.Contains(got, "(inactive; pins denied") {
+		t.Errorf("runMain() status = %q, %v, want the inactive pin", got, err)
+	}
+
+	script := `grep -q "namespace: payments" "$KUBECONFIG" && echo "$EKSDEFAULT_CONTEXT"`
+	if _, err := runMain([]string{self, "exec", "--", "sh", "-c", script}); err != nil || out.String() != "denied\n" {
+		t.Errorf("runMain() exec = %q, %v, want the pinned context", out.String(), err)
+	}
+	if _, err := runMain([]string{self, "exec", "up", "--", "sh", "-c", "exit 3"}); err == nil || !strings.Contains(err.Error(), "code 3") {
+		t.Errorf("runMain() exec error = %v, want the exit code", err)
+	}
+
+	if got, err := runMain([]string{self, "set", "--binding", "shell", "denied"}); err != nil || !strings.Contains(got, "export EKSDEFAULT_CONTEXT='denied'\n") {
+		t.Errorf("runMain() set with shell binding = %q, %v", got, err)
+	}
+	if got, _ := runMain([]string{self, "status"}); !strings.Contains(got, "context:        up\n") {
+		t.Errorf("runMain() status = %q, want the current-context unchanged by the shell binding", got)
+	}
+
+	// protected contexts must be confirmed
+	oldInteractive := interactive
+	interactive = func() bool { return false }
+	os.Setenv("EKSDEFAULT_PROTECTED_CONTEXTS", "denied")
+	for _, args := range [][]string{
+		{self, "exec", "denied", "--", "true"},
+		{self, "env", "denied"},
+		{self, "set", "--binding", "shell", "denied"},
+	} {
+		if _, err := runMain(args); err == nil || !strings.Contains(err.Error(), "--yes") {
+			t.Errorf("runMain(%v) error = %v, want a confirmation required", args, err)
+		}
+	}
+	if _, err := runMain([]string{self, "exec", "--yes", "denied", "--", "true"}); err != nil {
+		t.Errorf("runMain() exec with --yes error = %v", err)
+	}
+	os.Unsetenv("EKSDEFAULT_PROTECTED_CONTEXTS")
+	interactive = oldInteractive
+
+	// as activated by the shell hook
+	os.Setenv(eksdefault.UnpinnedKubeConfigEnv, config)
+	os.Setenv(eksdefault.PinEnv, pinPath)
+	os.Setenv("KUBECONFIG", pinned)
+	if got, err := runMain([]string{self, "status"}); err != nil || !strings.Contains(got, "pinned by:      "+pinPath+"\n") || !strings.Contains(got, "namespace:      payments") {
+		t.Errorf("runMain() status = %q, %v, want the active pin", got, err)
+	}
+	// the other commands work on the original kube config instead of the pinned copy
+	if got, err := runMain([]string{self, "ls", "-o", "short"}); err != nil || got != "denied\nup\n" {
+		t.Errorf("runMain() ls = %q, %v, want the contexts of the original kube config", got, err)
+	}
+	for _, args := range [][]string{{self, "profile", "live", "denied"}, {self, "set", "denied"}} {
+		if _, err := runMain(args); err != nil {
+			t.Errorf("runMain(%v) error = %v", args, err)
+		}
+	}
+	if original, err := eksdefault.GetConfigFile(eksdefault.WithPath(config)); err != nil || original.CurrentContext != "denied" {
+		t.Errorf("runMain() set did not change the original kube config: %v, %v", original, err)
+	}
+	if got, err := runMain([]string{self, "env", "--hook", "--shell", "bash"}); err != nil || strings.Contains(got, eksdefault.UnpinnedKubeConfigEnv) {
+		t.Errorf("runMain() env --hook = %q, %v, want the original kube config kept", got, err)
+	}
+	os.Chdir(dir)
+	got, err = runMain([]string{self, "env", "--hook"})
+	if err != nil || !strings.Contains(got, fmt.Sprintf("export KUBECONFIG='%s'\n", config)) || !strings.Contains(got, "unset "+eksdefault.PinEnv+"\n") {
+		t.Errorf("runMain() env --hook outside = %q, %v, want the variables reset", got, err)
+	}
+}
+
+func Test_runMain_config(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	dir, err := ioutil.TempDir("", "eksdefault-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("EKSDEFAULT_CONFIG", filepath.Join(dir, "config.yaml"))
+	defer func() {
+		os.Unsetenv("EKSDEFAULT_CONFIG")
+		os.Unsetenv("KUBECONFIG")
+		os.RemoveAll(dir)
+	}()
+
+	for _, args := range [][]string{
+		{"output", "short"},
+		{"list-columns", "context,namespace"},
+		{"protected-contexts", "cntxC"},
+	} {
+		if _, err := runMain(append([]string{self, "config", "set"}, args...)); err != nil {
+			t.Fatalf("runMain(config set %v) error = %v", args, err)
+		}
+	}
+	if _, err := runMain([]string{self, "config", "set", "colour", "red"}); err == nil {
+		t.Errorf("runMain() config set of an unknown key, want error")
+	}
+	if got, err := runMain([]string{self, "config", "view"}); err != nil || !strings.Contains(got, "output: short\n") || !strings.Contains(got, "- cntxC\n") {
+		t.Errorf("runMain() config view = %q, %v", got, err)
+	}
+	if _, ok := os.LookupEnv("EKSDEFAULT_OUTPUT"); ok {
+		t.Errorf("runMain() left the settings of the config file in the environment")
+	}
+	if got, err := runMain([]string{self, "--dry-run", "config", "set", "output", "table"}); err != nil || !strings.Contains(got, "-output: short\n+output: table\n") {
+		t.Errorf("runMain() config set in dry-run mode = %q, %v, want the diff", got, err)
+	}
+
+	// config file
+	if got, err := runMain([]string{self, "ls"}); err != nil || got != "cntxA\ncntxB\ncntxC\nminikube\n" {
+		t.Errorf("runMain() ls = %q, %v, want the short output of the config file", got, err)
+	}
+	// env overrules the config file
+	os.Setenv("EKSDEFAULT_OUTPUT", "table")
+	got, err := runMain([]string{self, "ls", "--current"})
+	os.Unsetenv("EKSDEFAULT_OUTPUT")
+	if err != nil || strings.Join(strings.Fields(got), " ") != "KUBE CONTEXT NAMESPACE cntxB bbbbb" {
+		t.Errorf("runMain() ls = %q, %v, want the table with the configured columns", got, err)
+	}
+	// flags overrule both
+	got, err = runMain([]string{self, "ls", "-o", "table", "--columns", "context,protected", "--name", "cntx*"})
+	if err != nil || strings.Join(strings.Fields(got), " ") != "KUBE CONTEXT PROTECTED cntxA no cntxB no cntxC yes" {
+		t.Errorf("runMain() ls = %q, %v, want the columns of the flag", got, err)
+	}
+	if _, err := runMain([]string{self, "ls", "-o", "table", "--columns", "context,colour"}); err == nil {
+		t.Errorf("runMain() ls with unknown column, want error")
+	}
+
+	if _, err := runMain([]string{self, "config", "unset", "output"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := runMain([]string{self, "config", "view"}); strings.Contains(got, "output") {
+		t.Errorf("runMain() config view = %q, want output removed", got)
+	}
+}
+
+func Test_runMain_globalFlags(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Unsetenv("KUBECONFIG")
+	dir, err := ioutil.TempDir("", "eksdefault-flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kube, creds := filepath.Join(dir, "config"), filepath.Join(dir, "credentials")
+	if err := ioutil.WriteFile(kube, testFileContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	credsContent, err := ioutil.ReadFile("testdata/.aws/credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(creds, credsContent, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runMain([]string{self, "--kubeconfig", kube, "--aws-credentials-file", creds, "set", "cntxC"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(kube); !strings.Contains(string(got), "current-context: cntxC") {
+		t.Errorf("runMain() did not switch the context in %s", kube)
+	}
+	if got, _ := ioutil.ReadFile("testdata/.kube/config"); string(got) != string(testFileContent) {
+		t.Errorf("runMain() changed the default kube config")
+	}
+	if got, _ := ioutil.ReadFile(creds); string(got) == string(credsContent) {
+		t.Errorf("runMain() did not change the default profile in %s", creds)
+	}
+	if got, _ := ioutil.ReadFile("testdata/.aws/credentials"); string(got) != string(credsContent) {
+		t.Errorf("runMain() changed the default AWS credentials file")
+	}
+	got, err := runMain([]string{self, "--kubeconfig", kube, "--aws-credentials-file", creds, "status"})
+	if err != nil || !strings.Contains(got, "context:        cntxC\n") || !strings.Contains(got, "active profile: dev\n") {
+		t.Errorf("runMain() status = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// mergeConfig imports the contexts, clusters and users of another kube config, e.g. the
+// file written by 'aws eks update-kubeconfig' for a new cluster.
+func mergeConfig(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "merge",
+		Aliases: []string{"import"},
+		Usage: "'merge <file> [-s skip|overwrite|rename] [-p <aws profile>]': Imports the contexts, clusters and users of another kube config." +
+			" Conflicting names are skipped, overwritten or renamed with the --suffix.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "strategy, s",
+				Value: eksdefault.MergeSkip,
+				Usage: "How to handle entries, which already exist with different settings: skip, overwrite or rename.",
+			},
+			cli.StringFlag{
+				Name:  "suffix",
+				Value: "-merged",
+				Usage: "Suffix for renamed entries.",
+			},
+			cli.StringFlag{
+				Name:  "profile, p",
+				Usage: "AWS profile to bind all imported contexts to.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("the path of the kube config to merge is required")
+			}
+			entries, err := file.Merge(c.Args().First(), eksdefault.MergeOptions{
+				Strategy: c.String("strategy"),
+				Suffix:   c.String("suffix"),
+				Profile:  c.String("profile"),
+			})
+			if err != nil {
+				return err
+			}
+			tbl := [][]string{}
+			for _, e := range entries {
+				action := e.Action
+				if e.Action == eksdefault.MergeRenamed {
+					action = fmt.Sprintf("renamed to %s", e.NewName)
+				}
+				tbl = append(tbl, []string{e.Kind, e.Name, action})
+			}
+			return printTabbed([]string{"KIND", "NAME", "ACTION"}, tbl)
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+)
+
+// pickRecentNamespace lets you select one of the recent namespaces of the context via a
+// numbered prompt. Without a terminal the recent namespaces are only listed.
+func pickRecentNamespace(file *eksdefault.KubeConfig, contextName string) error {
+	recent, err := file.RecentNamespaces(contextName)
+	if err != nil {
+		return err
+	}
+	if len(recent) < 1 {
+		return eksdefault.NoPreviousNamespace
+	}
+	if !interactive() {
+		output = strings.Join(recent, "\n") + "\n"
+		return nil
+	}
+	for idx, n := range recent {
+		fmt.Fprintf(stderr, "%3d) %s\n", idx, n)
+	}
+	fmt.Fprint(stderr, "Select namespace by number or name: ")
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	line = strings.TrimSpace(line)
+	if len(line) < 1 {
+		return fmt.Errorf("no namespace selected")
+	}
+	if idx, err := strconv.Atoi(line); err == nil {
+		if idx < 0 || idx >= len(recent) {
+			return fmt.Errorf("the number '%d' does not exist", idx)
+		}
+		line = recent[idx]
+	}
+	return file.AddNamespaceTo(contextName, line)
+}
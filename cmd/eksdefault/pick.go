@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+var (
+	pickCanceled = errors.New("no context selected")
+)
+
+// key codes handled by the picker
+const (
+	keyNone = iota
+	keyUp
+	keyDown
+	keyEnter
+	keyCancel
+	keyBackspace
+	keyClear
+	keyRune
+)
+
+type (
+	// picker is the state of the terminal UI for selecting a context.
+	picker struct {
+		file *eksdefault.KubeConfig
+		// selector limits the contexts to the ones with matching labels.
+		selector eksdefault.Selector
+		query    []rune
+		matches  []eksdefault.KubeContext
+		cursor   int
+		rows     int
+	}
+	scored struct {
+		ctx   eksdefault.KubeContext
+		score int
+	}
+)
+
+// fuzzyScore matches the runes of pattern in the given order against text and returns a
+// score, which is higher for consecutive matches and matches at the start of words.
+func fuzzyScore(pattern, text string) (int, bool) {
+	p, t := []rune(strings.ToLower(pattern)), []rune(strings.ToLower(text))
+	score, pi, last := 0, 0, -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 2
+		}
+		last = ti
+		pi++
+	}
+	return score, pi == len(p)
+}
+
+// pickText returns the text the fuzzy search runs against.
+func pickText(c eksdefault.KubeContext) string {
+	return strings.Join([]string{c.Name, c.AWSprofile, c.Context.Cluster, c.Context.Namespace}, " ")
+}
+
+// filter updates the matches for the current query; the best matches first.
+func (p *picker) filter() {
+	result := []scored{}
+	for _, c := range p.file.Contexts {
+		if c.Context == nil {
+			c.Context = &eksdefault.Context{}
+		}
+		if !p.selector.Matches(c.Labels) {
+			continue
+		}
+		if score, ok := fuzzyScore(string(p.query), pickText(c)); ok {
+			result = append(result, scored{c, score})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].score > result[j].score
+	})
+	p.matches = p.matches[:0]
+	for _, r := range result {
+		p.matches = append(p.matches, r.ctx)
+	}
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// readKey reads the next key press from the terminal in raw mode.
+func readKey(r *bufio.Reader) (int, rune, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return keyNone, 0, err
+	}
+	switch b {
+	case 3, 4: // ctrl-c, ctrl-d
+		return keyCancel, 0, nil
+	case '\r', '\n':
+		return keyEnter, 0, nil
+	case 127, 8:
+		return keyBackspace, 0, nil
+	case 21: // ctrl-u
+		return keyClear, 0, nil
+	case 16: // ctrl-p
+		return keyUp, 0, nil
+	case 14: // ctrl-n
+		return keyDown, 0, nil
+	case 27:
+		if r.Buffered() < 2 { // a single escape
+			return keyCancel, 0, nil
+		}
+		seq := make([]byte, 2)
+		if _, err := io.ReadFull(r, seq); err != nil {
+			return keyNone, 0, err
+		}
+		switch string(seq) {
+		case "[A", "OA":
+			return keyUp, 0, nil
+		case "[B", "OB":
+			return keyDown, 0, nil
+		}
+		return keyNone, 0, nil
+	}
+	if err := r.UnreadByte(); err != nil {
+		return keyNone, 0, err
+	}
+	ch, _, err := r.ReadRune()
+	if err != nil {
+		return keyNone, 0, err
+	}
+	if !unicode.IsPrint(ch) {
+		return keyNone, 0, nil
+	}
+	return keyRune, ch, nil
+}
+
+// handle applies a key press and returns the selected context name, if the selection is done.
+func (p *picker) handle(key int, ch rune) (string, bool, error) {
+	switch key {
+	case keyCancel:
+		return "", true, pickCanceled
+	case keyEnter:
+		if len(p.matches) < 1 {
+			return "", false, nil
+		}
+		return p.matches[p.cursor].Name, true, nil
+	case keyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyClear:
+		p.query = p.query[:0]
+		p.filter()
+	case keyRune:
+		p.query = append(p.query, ch)
+		p.cursor = 0
+		p.filter()
+	}
+	return "", false, nil
+}
+
+// preview returns the lines describing the context like the kube config resolves it.
+func (p *picker) preview(c eksdefault.KubeContext) []string {
+	_, idx, _ := p.file.GetContextBy(c.Name)
+	current := ""
+	if c.Name == p.file.CurrentContext {
+		current = " (current)"
+	}
+	cluster := c.Context.Cluster
+	if p.file.IsDangling(c) {
+		cluster += " (missing in kube config)"
+	}
+	lines := []string{
+		fmt.Sprintf("context:     %s%s", c.Name, current),
+		fmt.Sprintf("ID:          %d", idx),
+		fmt.Sprintf("aws profile: %s", c.AWSprofile),
+		fmt.Sprintf("cluster:     %s", cluster),
+		fmt.Sprintf("user:        %s", c.Context.User),
+		fmt.Sprintf("namespace:   %s", c.Context.Namespace),
+	}
+	if used := lastUsed(p.file, c.Name); len(used) > 0 {
+		lines = append(lines, fmt.Sprintf("last used:   %s", used))
+	}
+	if p.file.IsProtected(&c) {
+		lines = append(lines, "protected:   yes")
+	}
+	return lines
+}
+
+// render draws the query line, the matching contexts and the preview of the highlighted one.
+func (p *picker) render(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "> %s\r\n", string(p.query))
+	listRows := p.rows - 10
+	if listRows < 3 {
+		listRows = 3
+	}
+	start := 0
+	if p.cursor >= listRows {
+		start = p.cursor - listRows + 1
+	}
+	for i := start; i < len(p.matches) && i < start+listRows; i++ {
+		c := p.matches[i]
+		line := fmt.Sprintf("  %s  (%s)", c.Name, c.AWSprofile)
+		if i == p.cursor {
+			line = "\033[7m>" + line[1:] + "\033[0m"
+		}
+		b.WriteString(line + "\r\n")
+	}
+	fmt.Fprintf(&b, "  %d/%d\r\n", len(p.matches), len(p.file.Contexts))
+	b.WriteString(strings.Repeat("─", 40) + "\r\n")
+	if len(p.matches) > 0 {
+		for _, l := range p.preview(p.matches[p.cursor]) {
+			b.WriteString(l + "\r\n")
+		}
+	}
+	fmt.Fprint(w, b.String())
+}
+
+// run reads key presses until a context is selected or the selection is canceled.
+func (p *picker) run(r io.Reader, w io.Writer) (string, error) {
+	in := bufio.NewReader(r)
+	p.filter()
+	for {
+		p.render(w)
+		key, ch, err := readKey(in)
+		if err != nil {
+			return "", err
+		}
+		if name, done, err := p.handle(key, ch); done {
+			return name, err
+		}
+	}
+}
+
+// pickNumbered lists the contexts with their IDs and reads the ID or name of the selected
+// context from stdin. It is used, if no terminal is available.
+func pickNumbered(file *eksdefault.KubeConfig, query string, selector eksdefault.Selector) (string, error) {
+	p := &picker{file: file, selector: selector, query: []rune(query)}
+	p.filter()
+	if len(p.matches) < 1 {
+		return "", fmt.Errorf("no context matches '%s'", query)
+	}
+	for _, c := range p.matches {
+		_, idx, _ := file.GetContextBy(c.Name)
+		cc := " "
+		if c.Name == file.CurrentContext {
+			cc = "*"
+		}
+		fmt.Fprintf(stderr, "%s %3d) %s (%s)\n", cc, idx, c.Name, c.AWSprofile)
+	}
+	fmt.Fprint(stderr, "Select context by ID or name: ")
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if len(line) < 1 {
+		return "", pickCanceled
+	}
+	return idToName(line, file)
+}
+
+// pickContext lets you select the new current-context interactively; either via a terminal
+// UI with fuzzy search or via numbered prompts, if stdout is not a terminal.
+func pickContext(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "pick",
+		Aliases: []string{"select", "fzf"},
+		Usage: "'pick [<query>] [-l <selector>]': Selects the current-context via fuzzy search over name, profile, cluster and namespace." +
+			" Falls back to numbered prompts, if stdout is not a terminal.",
+		Flags: []cli.Flag{yesFlag, selectorFlag},
+		Action: func(c *cli.Context) error {
+			query := strings.Join(c.Args(), " ")
+			selector, err := eksdefault.ParseSelector(c.String("selector"))
+			if err != nil {
+				return err
+			}
+			var name string
+			if isTerminal(os.Stdout) && isTerminal(os.Stdin) {
+				name, err = pickTUI(file, query, selector)
+			} else {
+				name, err = pickNumbered(file, query, selector)
+			}
+			if err != nil {
+				return err
+			}
+			if err := file.SetContextTo(name); err != nil {
+				if err == eksdefault.NoProfilSet {
+					return fmt.Errorf("%v.\nYou can run also 'eksdefault profile <aws profile> %s' to set an AWS profile for this context", err, name)
+				}
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// pickTUI runs the picker inside the alternate screen of the terminal.
+func pickTUI(file *eksdefault.KubeConfig, query string, selector eksdefault.Selector) (string, error) {
+	restore, err := makeRaw()
+	if err != nil {
+		return pickNumbered(file, query, selector)
+	}
+	defer restore()
+	fmt.Fprint(os.Stdout, "\033[?1049h")
+	defer fmt.Fprint(os.Stdout, "\033[?1049l")
+	p := &picker{file: file, selector: selector, query: []rune(query), rows: terminalHeight()}
+	return p.run(os.Stdin, os.Stdout)
+}
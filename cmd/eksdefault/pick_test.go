@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/peterbueschel/eksdefault"
+)
+
+func testKubeConfig() *eksdefault.KubeConfig {
+	return &eksdefault.KubeConfig{
+		CurrentContext: "cntxB",
+		Contexts: []eksdefault.KubeContext{
+			{Name: "cntxA", AWSprofile: "live", Context: &eksdefault.Context{Cluster: "clstrA", Namespace: "aaaaa"}},
+			{Name: "cntxB", AWSprofile: "live", Context: &eksdefault.Context{Cluster: "clstrB", Namespace: "bbbbb"}},
+			{Name: "cntxC", AWSprofile: "dev", Context: &eksdefault.Context{Cluster: "clstrC", Namespace: "payments"}},
+			{Name: "minikube", Context: &eksdefault.Context{Cluster: "minikube"}},
+		},
+	}
+}
+
+func Test_fuzzyScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		text      string
+		wantMatch bool
+	}{
+		{name: "0positive - empty pattern", pattern: "", text: "cntxA", wantMatch: true},
+		{name: "1positive - subsequence", pattern: "cxa", text: "cntxA live", wantMatch: true},
+		{name: "2positive - case insensitive", pattern: "PAY", text: "cntxC dev clstrC payments", wantMatch: true},
+		{name: "3negative - wrong order", pattern: "axc", text: "cntxA", wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := fuzzyScore(tt.pattern, tt.text); ok != tt.wantMatch {
+				t.Errorf("fuzzyScore() = %v, want %v", ok, tt.wantMatch)
+			}
+		})
+	}
+	// consecutive matches rank higher than scattered ones
+	consecutive, _ := fuzzyScore("dev", "cntxC dev")
+	scattered, _ := fuzzyScore("dev", "d-e-v")
+	if consecutive <= scattered {
+		t.Errorf("fuzzyScore() consecutive = %d, scattered = %d, want consecutive higher", consecutive, scattered)
+	}
+}
+
+func Test_picker_run(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		keys    string
+		want    string
+		wantErr bool
+	}{
+		{name: "0positive - enter selects the first match", keys: "\r", want: "cntxA"},
+		{name: "1positive - arrow down", keys: "\x1b[B\x1b[B\r", want: "cntxC"},
+		{name: "2positive - fuzzy query by namespace", keys: "paym\r", want: "cntxC"},
+		{name: "3positive - initial query and backspace", query: "minik", keys: "\x7f\x7f\x7f\x7f\x7fcntxb\r", want: "cntxB"},
+		{name: "4positive - ctrl-u clears the query", query: "xxxx", keys: "\x15\x0e\r", want: "cntxB"},
+		{name: "5negative - ctrl-c cancels", keys: "\x03", wantErr: true},
+		{name: "6negative - end of input", keys: "xx", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &picker{file: testKubeConfig(), query: []rune(tt.query), rows: 24}
+			var screen bytes.Buffer
+			got, err := p.run(strings.NewReader(tt.keys), &screen)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("picker.run() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("picker.run() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(screen.String(), "aws profile:") {
+				t.Errorf("picker.run() screen has no preview:\n%s", screen.String())
+			}
+		})
+	}
+}
+
+func Test_runMain_pick(t *testing.T) {
+	os.Setenv("HOME", "testdata")
+	os.Setenv("KUBECONFIG", "testdata/.kube/config")
+	defer func() {
+		stdin, stderr = os.Stdin, os.Stderr
+		os.Unsetenv("KUBECONFIG")
+		if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	tests := []struct {
+		name               string
+		args               []string
+		input              string
+		wantErr            bool
+		wantPrompt         string
+		wantCurrentContext string
+	}{
+		{
+			name:               "0pick - positive - select by ID",
+			args:               []string{self, "pick"},
+			input:              "2\n",
+			wantPrompt:         "*   1) cntxB (live)",
+			wantCurrentContext: "cntxC\n",
+		},
+		{
+			name:               "1pick - positive - select by name after query",
+			args:               []string{self, "pick", "live"},
+			input:              "cntxA\n",
+			wantPrompt:         "    0) cntxA (live)",
+			wantCurrentContext: "cntxA\n",
+		},
+		{
+			name:               "2pick - negative - nothing selected",
+			args:               []string{self, "pick"},
+			input:              "",
+			wantErr:            true,
+			wantCurrentContext: "cntxB\n",
+		},
+		{
+			name:               "3pick - negative - no match",
+			args:               []string{self, "pick", "xxxxxx"},
+			wantErr:            true,
+			wantCurrentContext: "cntxB\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt bytes.Buffer
+			stdin, stderr = strings.NewReader(tt.input), &prompt
+			if _, err := runMain(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("runMain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !strings.Contains(prompt.String(), tt.wantPrompt) {
+				t.Errorf("runMain() prompt =\n%s, want it to contain %s", prompt.String(), tt.wantPrompt)
+			}
+			if got, _ := runMain([]string{self, "is"}); got != tt.wantCurrentContext {
+				t.Errorf("runMain() got = %+v, wantCurrentContext = %+v", got, tt.wantCurrentContext)
+			}
+			if err := ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+const (
+	bashHook = `# eksdefault bash hook; load it via: source <(eksdefault hook bash)
+_eksdefault_hook() {
+	if [ "$PWD" != "$_EKSDEFAULT_PWD" ]; then
+		_EKSDEFAULT_PWD="$PWD"
+		eval "$(eksdefault env --hook --shell bash)"
+	fi
+}
+case ";${PROMPT_COMMAND};" in
+	*";_eksdefault_hook;"*) ;;
+	*) PROMPT_COMMAND="_eksdefault_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`
+	zshHook = `# eksdefault zsh hook; load it via: source <(eksdefault hook zsh)
+_eksdefault_hook() {
+	eval "$(eksdefault env --hook --shell zsh)"
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _eksdefault_hook
+_eksdefault_hook
+`
+	fishHook = `# eksdefault fish hook; load it via: eksdefault hook fish | source
+function __eksdefault_hook --on-variable PWD
+	eksdefault env --hook --shell fish | source
+end
+__eksdefault_hook
+`
+)
+
+var hookScripts = map[string]string{
+	"bash": bashHook,
+	"zsh":  zshHook,
+	"fish": fishHook,
+}
+
+// shellFlag selects the syntax of the printed environment variables.
+var shellFlag = cli.StringFlag{
+	Name:   "shell",
+	Value:  "bash",
+	Usage:  "Prints the variables for the given shell; 'bash', 'zsh' or 'fish'.",
+	EnvVar: "EKSDEFAULT_SHELL",
+}
+
+// shellSet returns the statement setting the environment variable in the given shell.
+func shellSet(shell, name, value string) string {
+	if shell == "fish" {
+		value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+		return fmt.Sprintf("set -gx %s '%s';\n", name, value)
+	}
+	return fmt.Sprintf("export %s='%s'\n", name, strings.Replace(value, `'`, `'\''`, -1))
+}
+
+// shellUnset returns the statement removing the environment variable in the given shell.
+func shellUnset(shell, name string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -e %s;\n", name)
+	}
+	return fmt.Sprintf("unset %s\n", name)
+}
+
+// pinActive reports whether a shell hook or 'env' replaced the kube config by a pinned one.
+func pinActive() bool {
+	return len(os.Getenv(eksdefault.UnpinnedKubeConfigEnv)) > 0
+}
+
+// currentPin returns the pin of the working directory or nil, if there is none.
+func currentPin() (*eksdefault.Pin, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return eksdefault.FindPin(dir)
+}
+
+// pinFor returns the context given as argument as pin or the pin of the working directory.
+func pinFor(c *cli.Context, file *eksdefault.KubeConfig, arg string) (*eksdefault.Pin, error) {
+	if len(arg) > 0 || len(c.String("selector")) > 0 {
+		name, err := contextArg(c, file, arg)
+		if err != nil {
+			return nil, err
+		}
+		return &eksdefault.Pin{Context: name}, nil
+	}
+	pin, err := currentPin()
+	if err != nil {
+		return nil, err
+	}
+	if pin == nil {
+		return nil, fmt.Errorf("no context given and no %s file found in the working directory or its parents", eksdefault.PinFileName)
+	}
+	return pin, nil
+}
+
+// unpinnedProfile returns the AWS_PROFILE set before any pin became active.
+func unpinnedProfile() string {
+	if pinActive() {
+		return os.Getenv(eksdefault.UnpinnedProfileEnv)
+	}
+	return os.Getenv("AWS_PROFILE")
+}
+
+// pinnedEnv returns the environment variables binding a process to the pinned context.
+func pinnedEnv(file *eksdefault.KubeConfig, pin *eksdefault.Pin) (map[string]string, error) {
+	path, err := file.WritePinned(pin)
+	if err != nil {
+		return nil, err
+	}
+	ctx, _, err := file.GetContextBy(pin.Context)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{
+		"KUBECONFIG":         path,
+		"AWS_PROFILE":        unpinnedProfile(),
+		eksdefault.PinEnv:    pin.Path,
+		"EKSDEFAULT_CONTEXT": pin.Context,
+	}
+	if len(ctx.AWSprofile) > 0 {
+		env["AWS_PROFILE"] = ctx.AWSprofile
+	}
+	if len(credentialsFile) > 0 {
+		env["AWS_SHARED_CREDENTIALS_FILE"] = credentialsFile
+	}
+	return env, nil
+}
+
+// bindShell returns the statements binding a shell to the pinned context.
+func bindShell(shell string, file *eksdefault.KubeConfig, pin *eksdefault.Pin) (string, error) {
+	if _, ok := hookScripts[shell]; !ok {
+		return "", fmt.Errorf("unknown shell '%s'; one of bash, zsh, fish", shell)
+	}
+	env, err := pinnedEnv(file, pin)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if !pinActive() {
+		b.WriteString(shellSet(shell, eksdefault.UnpinnedKubeConfigEnv, file.Path))
+		b.WriteString(shellSet(shell, eksdefault.UnpinnedProfileEnv, os.Getenv("AWS_PROFILE")))
+	}
+	for _, name := range []string{"KUBECONFIG", "AWS_PROFILE", eksdefault.PinEnv, "EKSDEFAULT_CONTEXT"} {
+		if len(env[name]) > 0 {
+			b.WriteString(shellSet(shell, name, env[name]))
+		} else {
+			b.WriteString(shellUnset(shell, name))
+		}
+	}
+	return b.String(), nil
+}
+
+// resetEnv returns the statements restoring the variables changed by an active pin.
+func resetEnv(shell string) string {
+	var b strings.Builder
+	b.WriteString(shellSet(shell, "KUBECONFIG", os.Getenv(eksdefault.UnpinnedKubeConfigEnv)))
+	if profile := os.Getenv(eksdefault.UnpinnedProfileEnv); len(profile) > 0 {
+		b.WriteString(shellSet(shell, "AWS_PROFILE", profile))
+	} else {
+		b.WriteString(shellUnset(shell, "AWS_PROFILE"))
+	}
+	for _, name := range []string{eksdefault.UnpinnedKubeConfigEnv, eksdefault.UnpinnedProfileEnv, eksdefault.PinEnv, "EKSDEFAULT_CONTEXT"} {
+		b.WriteString(shellUnset(shell, name))
+	}
+	return b.String()
+}
+
+// envPinned prints the environment variables, which bind a shell to the context pinned by the
+// '.eksdefault' file of the working directory or to the given context.
+func envPinned(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name: "env",
+		Usage: "'env [<context>|<ID>|-l <selector>] [--shell bash|zsh|fish]': Prints KUBECONFIG and AWS_PROFILE for the context pinned by the " +
+			eksdefault.PinFileName + " file of the working directory or one of its parents; load them via 'eval \"$(eksdefault env)\"'.",
+		Flags: []cli.Flag{
+			selectorFlag,
+			shellFlag,
+			yesFlag,
+			cli.BoolFlag{
+				Name:  "reset",
+				Usage: "Prints the statements restoring the variables from before the pin.",
+			},
+			cli.BoolFlag{
+				Name:  "hook",
+				Usage: "Used by the shell hook; only follows the pin file and resets the variables outside of pinned directories.",
+			},
+		},
+		BashComplete: func(c *cli.Context) {
+			completeContexts(file)
+		},
+		Action: func(c *cli.Context) error {
+			shell := c.String("shell")
+			if _, ok := hookScripts[shell]; !ok {
+				return fmt.Errorf("unknown shell '%s'; one of bash, zsh, fish", shell)
+			}
+			if c.Bool("reset") {
+				if pinActive() {
+					output = resetEnv(shell)
+				}
+				return nil
+			}
+			var (
+				pin *eksdefault.Pin
+				err error
+			)
+			if c.Bool("hook") {
+				if pin, err = currentPin(); err != nil {
+					return err
+				}
+				if pin == nil {
+					if pinActive() {
+						output = resetEnv(shell)
+					}
+					return nil
+				}
+			} else {
+				pin, err = pinFor(c, file, c.Args().First())
+			}
+			if err != nil {
+				return err
+			}
+			output, err = bindShell(shell, file, pin)
+			return err
+		},
+	}
+}
+
+// execPinned runs a command bound to the context pinned by the '.eksdefault' file of the
+// working directory or to the given context, without changing the current-context.
+func execPinned(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name: "exec",
+		Usage: "'exec [<context>|<ID>|-l <selector>] -- <command> [<args>...]': Runs the command with KUBECONFIG and AWS_PROFILE set for the context pinned by the " +
+			eksdefault.PinFileName + " file of the working directory or one of its parents.",
+		Flags: []cli.Flag{
+			selectorFlag,
+			yesFlag,
+		},
+		BashComplete: func(c *cli.Context) {
+			completeContexts(file)
+		},
+		Action: func(c *cli.Context) error {
+			args, command := splitCommand(c)
+			if len(command) < 1 {
+				return fmt.Errorf("the command is required after '--'")
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("only a single context is allowed")
+			}
+			pin, err := pinFor(c, file, strings.Join(args, ""))
+			if err != nil {
+				return err
+			}
+			env, err := pinnedEnv(file, pin)
+			if err != nil {
+				return err
+			}
+			cmd := exec.Command(command[0], command[1:]...)
+			cmd.Env = os.Environ()
+			for name, value := range env {
+				cmd.Env = append(cmd.Env, name+"="+value)
+			}
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+			err = cmd.Run()
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return fmt.Errorf("the command exited with code %d", exitErr.ExitCode())
+			}
+			return err
+		},
+	}
+}
+
+// shellHook prints the script, which binds the shell to the pinned context whenever the
+// working directory changes.
+func shellHook() *cli.Command {
+	shells := []string{"bash", "zsh", "fish"}
+	return &cli.Command{
+		Name:  "hook",
+		Usage: "'hook <bash|zsh|fish>': Prints the shell hook, which follows the " + eksdefault.PinFileName + " files while changing directories.",
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() < 1 {
+				output += strings.Join(shells, "\n") + "\n"
+			}
+		},
+		Action: func(c *cli.Context) error {
+			script, ok := hookScripts[strings.ToLower(c.Args().First())]
+			if !ok {
+				return fmt.Errorf("a shell is required; one of %s", strings.Join(shells, ", "))
+			}
+			output = script
+			return nil
+		},
+	}
+}
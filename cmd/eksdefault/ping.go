@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// yesNo formats a flag of the ping results.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// pingContexts checks the API servers of the given contexts.
+func pingContexts(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "ping",
+		Aliases: []string{"health"},
+		Usage: "'ping [<context>|<ID> ...|-l <selector>|--all]': Checks in parallel whether the API servers are reachable and accept the credentials." +
+			" Without arguments the current-context is checked.",
+		Flags: []cli.Flag{
+			selectorFlag,
+			cli.BoolFlag{
+				Name:  "all, a",
+				Usage: "Checks all contexts.",
+			},
+			cli.DurationFlag{
+				Name:  "timeout, t",
+				Value: 5 * time.Second,
+				Usage: "Timeout per context including the credential plugin.",
+			},
+		},
+		BashComplete: func(c *cli.Context) {
+			completeContexts(file)
+		},
+		Action: func(c *cli.Context) error {
+			names := []string{}
+			switch {
+			case c.Bool("all"):
+				names = file.GetContextNames()
+			case c.NArg() > 0 || len(c.String("selector")) > 0:
+				var err error
+				if names, err = contextArgs(c, file, c.Args()); err != nil {
+					return err
+				}
+			case len(file.CurrentContext) > 0:
+				names = append(names, file.CurrentContext)
+			default:
+				return fmt.Errorf("no current-context set; name the contexts to check or use --all")
+			}
+			tbl := [][]string{}
+			for _, r := range file.PingAll(names, c.Duration("timeout")) {
+				latency, errMsg := "", ""
+				if r.Reachable {
+					latency = r.Latency.Round(time.Millisecond).String()
+				}
+				if r.Err != nil {
+					errMsg = r.Err.Error()
+				}
+				tbl = append(tbl, []string{r.Context, yesNo(r.Reachable), yesNo(r.AuthOK), r.Version, latency, errMsg})
+			}
+			return printTabbed([]string{"CONTEXT", "REACHABLE", "AUTH", "VERSION", "LATENCY", "ERROR"}, tbl)
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+const defaultPromptFormat = "⎈ {{.Context}}{{if .Namespace}}/{{.Namespace}}{{end}}{{if .Profile}} ({{.Profile}}){{end}}"
+
+var (
+	ansiColors = map[string]string{
+		"bold":    "1",
+		"black":   "30",
+		"red":     "31",
+		"green":   "32",
+		"yellow":  "33",
+		"blue":    "34",
+		"magenta": "35",
+		"cyan":    "36",
+		"white":   "37",
+	}
+	// nonPrintingEscapes wrap the color codes, so that the shell can calculate the prompt width.
+	nonPrintingEscapes = map[string][2]string{
+		"bash": {`\[`, `\]`},
+		"zsh":  {"%{", "%}"},
+	}
+)
+
+// colorFor returns the ANSI escape sequence configured for the prompt. The colors are given as
+// comma separated list of '<glob>=<color>[+<color>]' pairs, where the glob is matched first
+// against the aws profile and then against the context name.
+func colorFor(info *eksdefault.PromptInfo, colors string) (string, error) {
+	for _, pair := range strings.Split(colors, ",") {
+		if len(strings.TrimSpace(pair)) < 1 {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return "", fmt.Errorf("invalid prompt color '%s'; use '<profile or context>=<color>'", pair)
+		}
+		pattern := strings.TrimSpace(kv[0])
+		if !eksdefault.MatchGlob(pattern, info.Profile) && !eksdefault.MatchGlob(pattern, info.Context) {
+			continue
+		}
+		codes := []string{}
+		for _, name := range strings.Split(kv[1], "+") {
+			code, ok := ansiColors[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return "", fmt.Errorf("unknown prompt color '%s'", name)
+			}
+			codes = append(codes, code)
+		}
+		return "\033[" + strings.Join(codes, ";") + "m", nil
+	}
+	return "", nil
+}
+
+// prompt prints a short segment about the current-context for shell prompts. It avoids
+// parsing the whole kube config, because it runs on every prompt.
+func prompt() *cli.Command {
+	return &cli.Command{
+		Name:  "prompt",
+		Usage: "'prompt [-f <template>] [--colors <profile>=<color>,...] [--shell bash|zsh]': Prints the current-context as shell prompt segment.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "format, f",
+				Value:  defaultPromptFormat,
+				Usage:  "Go template with the fields .Context, .Namespace, .Cluster, .User, .Profile and .ActiveProfile.",
+				EnvVar: "EKSDEFAULT_PROMPT_FORMAT",
+			},
+			cli.StringFlag{
+				Name:   "colors",
+				Usage:  "Comma separated '<profile or context glob>=<color>' pairs like 'live=red+bold,dev*=green'.",
+				EnvVar: "EKSDEFAULT_PROMPT_COLORS",
+			},
+			cli.StringFlag{
+				Name:   "shell",
+				Usage:  "Wraps the colors for the prompt of the given shell; 'bash' or 'zsh'.",
+				EnvVar: "EKSDEFAULT_PROMPT_SHELL",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			info, err := eksdefault.ReadPromptInfo(options...)
+			if err != nil {
+				return err
+			}
+			if len(info.Context) < 1 {
+				return nil
+			}
+			tmpl, err := template.New("prompt").Parse(c.String("format"))
+			if err != nil {
+				return err
+			}
+			var b bytes.Buffer
+			if err := tmpl.Execute(&b, info); err != nil {
+				return err
+			}
+			color, err := colorFor(info, c.String("colors"))
+			if err != nil {
+				return err
+			}
+			if len(color) < 1 {
+				output = b.String() + "\n"
+				return nil
+			}
+			reset := "\033[0m"
+			if esc, ok := nonPrintingEscapes[c.String("shell")]; ok {
+				color = esc[0] + color + esc[1]
+				reset = esc[0] + reset + esc[1]
+			}
+			output = color + b.String() + reset + "\n"
+			return nil
+		},
+	}
+}
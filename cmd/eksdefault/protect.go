@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+var yesFlag = cli.BoolFlag{
+	Name:   "yes, y",
+	Usage:  "Switches to protected contexts without asking for a confirmation.",
+	EnvVar: "EKSDEFAULT_YES",
+}
+
+// confirmProtected returns the confirmation asked before switching to a protected context.
+// It passes with the --yes flag; otherwise the name of the context must be typed in. Without
+// a terminal and without --yes the switch is refused.
+func confirmProtected(c *cli.Context, file *eksdefault.KubeConfig) func(*eksdefault.KubeContext) error {
+	return func(ctx *eksdefault.KubeContext) error {
+		if c.Bool("yes") || c.GlobalBool("yes") {
+			return nil
+		}
+		if !interactive() {
+			return fmt.Errorf("%v. Use '--yes' to confirm it", eksdefault.NotConfirmed)
+		}
+		account := file.AccountOf(ctx)
+		if len(account) < 1 {
+			account = "unknown"
+		}
+		fmt.Fprintf(stderr, "The context '%s' is protected.\n", ctx.Name)
+		fmt.Fprintf(stderr, "  aws profile: %s\n", ctx.AWSprofile)
+		fmt.Fprintf(stderr, "  account:     %s\n", account)
+		fmt.Fprintf(stderr, "  cluster:     %s\n", ctx.Context.Cluster)
+		fmt.Fprint(stderr, "Type the name of the context to confirm: ")
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if strings.TrimSpace(line) != ctx.Name {
+			return eksdefault.NotConfirmed
+		}
+		return nil
+	}
+}
+
+// protectContext marks contexts as protected or, if protect is false, removes the mark.
+func protectContext(file *eksdefault.KubeConfig, protect bool) *cli.Command {
+	name, usage := "protect", "'protect <context>|<ID> ...|-l <selector>': Requires a confirmation before switching to the given contexts."
+	if !protect {
+		name, usage = "unprotect", "'unprotect <context>|<ID> ...|-l <selector>': Removes the protection from the given contexts."
+	}
+	return &cli.Command{
+		Name:  name,
+		Usage: usage,
+		BashComplete: func(c *cli.Context) {
+			completeContexts(file)
+		},
+		Flags: []cli.Flag{selectorFlag},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 && len(c.String("selector")) < 1 {
+				return fmt.Errorf(
+					"the ID or name of an existing context is required",
+				)
+			}
+			names, err := contextArgs(c, file, c.Args())
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if err := file.SetProtected(name, protect); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// confirmPrune asks before deleting the stale contexts. It passes with the --yes flag.
+func confirmPrune(c *cli.Context, n int) error {
+	if c.Bool("yes") || c.GlobalBool("yes") {
+		return nil
+	}
+	if !interactive() {
+		return fmt.Errorf("pruning was not confirmed. Use '--yes' to confirm it")
+	}
+	fmt.Fprintf(stderr, "Delete %d contexts together with their unused clusters and users? [y/N] ", n)
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("pruning was not confirmed")
+	}
+	return nil
+}
+
+// pruneContexts deletes the contexts whose cluster is gone.
+func pruneContexts(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name: "prune",
+		Usage: "'prune [-m eks|reach]': Deletes contexts whose cluster no longer exists together with their unused clusters and users." +
+			" EKS clusters are looked up via the EKS API, all others by connecting to the server.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "method, m",
+				Value: "eks",
+				Usage: "'eks' asks the EKS API and connects to the server of all other clusters; 'reach' only connects to the servers.",
+			},
+			cli.StringFlag{
+				Name:   "endpoint",
+				Usage:  "URL of the EKS API used instead of https://eks.<region>.amazonaws.com.",
+				EnvVar: "EKSDEFAULT_EKS_ENDPOINT",
+			},
+			cli.DurationFlag{
+				Name:  "timeout, t",
+				Value: 5 * time.Second,
+				Usage: "Timeout of a single check.",
+			},
+			cli.BoolFlag{
+				Name:  "yes, y",
+				Usage: "Deletes the stale contexts without asking for a confirmation.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			var probe eksdefault.ClusterProbe = &eksdefault.ReachabilityProbe{Timeout: c.Duration("timeout")}
+			switch c.String("method") {
+			case "eks":
+				probe = &eksdefault.EKSProbe{
+					Endpoint: c.String("endpoint"),
+					Client:   &http.Client{Timeout: c.Duration("timeout")},
+					Fallback: probe,
+					Options:  options,
+				}
+			case "reach":
+			default:
+				return fmt.Errorf("unknown method '%s'; use eks or reach", c.String("method"))
+			}
+			stale, failed := file.StaleContexts(probe)
+			for _, f := range failed {
+				fmt.Fprintf(stderr, "cannot check '%s': %s\n", f.Name, f.Reason)
+			}
+			if len(stale) < 1 {
+				output = "no stale contexts found"
+				return nil
+			}
+			names := make([]string, len(stale))
+			for i, s := range stale {
+				names[i] = s.Name
+				fmt.Fprintf(stderr, "%s: %s\n", s.Name, s.Reason)
+			}
+			if !file.DryRun {
+				if err := confirmPrune(c, len(stale)); err != nil {
+					return err
+				}
+			}
+			result, err := file.Prune(names)
+			if err != nil {
+				return err
+			}
+			tbl := [][]string{}
+			for _, e := range []struct {
+				kind  string
+				names []string
+			}{{"context", result.Contexts}, {"cluster", result.Clusters}, {"user", result.Users}} {
+				for _, name := range e.names {
+					tbl = append(tbl, []string{e.kind, name})
+				}
+			}
+			return printTabbed([]string{"KIND", "REMOVED"}, tbl)
+		},
+	}
+}
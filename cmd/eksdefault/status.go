@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/peterbueschel/eksdefault"
+	"github.com/urfave/cli"
+)
+
+// pinInfo describes the pin file binding the shell or the working directory to a context.
+func pinInfo() (string, error) {
+	if path := os.Getenv(eksdefault.PinEnv); pinActive() && len(path) > 0 {
+		return path, nil
+	}
+	pin, err := currentPin()
+	if err != nil || pin == nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (inactive; pins %s, use 'eksdefault exec' or the shell hook)", pin.Path, pin.Context), nil
+}
+
+// getStatus prints the current-context together with its settings, the default AWS profile
+// and the remaining time of an expiring switch.
+func getStatus(file *eksdefault.KubeConfig) *cli.Command {
+	return &cli.Command{
+		Name:    "status",
+		Aliases: []string{"st"},
+		Usage:   "'status': Prints the current-context, its settings and the default AWS profile.",
+		Action: func(c *cli.Context) error {
+			info, err := eksdefault.ReadPromptInfo(options...)
+			if err != nil {
+				return err
+			}
+			file := file
+			if pinActive() {
+				// the shell uses the pinned kube config
+				if file, err = eksdefault.GetConfigFile(options...); err != nil {
+					return err
+				}
+			}
+			if len(file.CurrentContext) < 1 {
+				output = fmt.Sprintf("no current-context set\nactive profile: %s\n", info.ActiveProfile)
+				return nil
+			}
+			ctx, _, err := file.GetContextBy(file.CurrentContext)
+			if err != nil {
+				return err
+			}
+			protected := "no"
+			if file.IsProtected(ctx) {
+				protected = "yes"
+			}
+			lines := [][]string{
+				{"context", ctx.Name},
+				{"namespace", ctx.Context.Namespace},
+				{"cluster", ctx.Context.Cluster},
+				{"user", ctx.Context.User},
+				{"aws profile", ctx.AWSprofile},
+				{"active profile", info.ActiveProfile},
+				{"protected", protected},
+			}
+			if expiry := expiryInfo(ctx.Name); len(expiry) > 0 {
+				lines = append(lines, []string{"expiry", expiry})
+			}
+			if pin, err := pinInfo(); err != nil {
+				return err
+			} else if len(pin) > 0 {
+				lines = append(lines, []string{"pinned by", pin})
+			}
+			var b strings.Builder
+			for _, l := range lines {
+				fmt.Fprintf(&b, "%-15s %s\n", l[0]+":", l[1])
+			}
+			output = b.String()
+			return nil
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+var (
+	// stdin and stderr are used for interactive prompts; tests replace them.
+	stdin  io.Reader = os.Stdin
+	stderr io.Writer = os.Stderr
+	// stdout receives the output of commands run by eksdefault.
+	stdout io.Writer = os.Stdout
+	// isTerminal reports whether the file is connected to a terminal.
+	isTerminal = func(f *os.File) bool {
+		fi, err := f.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0
+	}
+	// interactive reports whether questions can be asked via stdin.
+	interactive = func() bool {
+		return isTerminal(os.Stdin)
+	}
+)
+
+// stty runs the stty command against the terminal connected to stdin.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// makeRaw switches the terminal into raw mode and returns a function restoring the
+// previous mode.
+func makeRaw() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() { _, _ = stty(state) }, nil
+}
+
+// terminalHeight returns the number of rows of the terminal or 24 if unknown.
+func terminalHeight() int {
+	size, err := stty("size")
+	if err != nil {
+		return 24
+	}
+	if f := strings.Fields(size); len(f) == 2 {
+		if rows, err := strconv.Atoi(f[0]); err == nil && rows > 0 {
+			return rows
+		}
+	}
+	return 24
+}
@@ -0,0 +1,254 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const configFileName = "config.yaml"
+
+// Config holds the settings of eksdefault itself. Every setting can be overruled by its
+// environment variable listed in ConfigEnv and the flags of the commands.
+type Config struct {
+	// Output is the default output of 'list'; 'table' or 'short'.
+	Output string `yaml:"output,omitempty"`
+	// Binding defines what 'set' changes; 'global' switches the current-context and the
+	// default AWS profile, 'shell' prints the variables binding only the current shell.
+	Binding string `yaml:"binding,omitempty"`
+	// ProtectedContexts are globs of contexts, which require a confirmation like contexts
+	// marked as protected in the kube config.
+	ProtectedContexts []string `yaml:"protected-contexts,omitempty"`
+	HooksDir          string   `yaml:"hooks-dir,omitempty"`
+	// FallbackProfile is used as default AWS profile for contexts without aws-profile.
+	FallbackProfile string `yaml:"fallback-profile,omitempty"`
+	// ListColumns are the columns of the table printed by 'list'.
+	ListColumns []string `yaml:"list-columns,omitempty"`
+	// Colors are '<glob>=<color>' pairs for the prompt; the first matching pair is used.
+	Colors            []string `yaml:"colors,omitempty"`
+	PromptFormat      string   `yaml:"prompt-format,omitempty"`
+	Audit             string   `yaml:"audit,omitempty"`
+	SafeContext       string   `yaml:"safe-context,omitempty"`
+	ValidateNamespace bool     `yaml:"validate-namespace,omitempty"`
+	Path              string   `yaml:"-"`
+	fs                FileSystem
+}
+
+var (
+	// ConfigEnv maps the keys of the config file to the environment variables overruling them.
+	ConfigEnv = map[string]string{
+		"output":             "EKSDEFAULT_OUTPUT",
+		"binding":            "EKSDEFAULT_BINDING",
+		"protected-contexts": "EKSDEFAULT_PROTECTED_CONTEXTS",
+		"hooks-dir":          "EKSDEFAULT_HOOKS_DIR",
+		"fallback-profile":   "EKSDEFAULT_FALLBACK_PROFILE",
+		"list-columns":       "EKSDEFAULT_LIST_COLUMNS",
+		"colors":             "EKSDEFAULT_PROMPT_COLORS",
+		"prompt-format":      "EKSDEFAULT_PROMPT_FORMAT",
+		"audit":              "EKSDEFAULT_AUDIT",
+		"safe-context":       "EKSDEFAULT_SAFE_CONTEXT",
+		"validate-namespace": "EKSDEFAULT_VALIDATE_NAMESPACE",
+	}
+	// configValues are the allowed values of the keys with a fixed set of values.
+	configValues = map[string][]string{
+		"output":  {"table", "short"},
+		"binding": {"global", "shell"},
+	}
+)
+
+// ConfigPath returns the path of the config file; either given by EKSDEFAULT_CONFIG or the
+// 'config.yaml' inside the config directory.
+func ConfigPath() string {
+	if p := os.Getenv("EKSDEFAULT_CONFIG"); len(p) > 0 {
+		return p
+	}
+	return filepath.Join(ConfigDir(), configFileName)
+}
+
+// ConfigKeys returns the keys of the config file in alphabetical order.
+func ConfigKeys() []string {
+	keys := []string{}
+	for key := range ConfigEnv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ReadConfig reads the config file. A missing file results in an empty config.
+func ReadConfig(opts ...Option) (*Config, error) {
+	return newKubeConfig(opts).readConfig()
+}
+
+// readConfig reads the config file from the configured path and FileSystem.
+func (k *KubeConfig) readConfig() (*Config, error) {
+	c := &Config{Path: k.configPath(), fs: k.fs()}
+	f, err := c.fs.ReadFile(c.Path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := yaml.UnmarshalStrict(f, c); err != nil {
+		return c, fmt.Errorf("[CONFIG] unable to read %s: %v", c.Path, err)
+	}
+	for key, allowed := range configValues {
+		if value := c.Get(key); len(value) > 0 && !inList(value, allowed) {
+			return c, fmt.Errorf("[CONFIG] invalid %s '%s' in %s; one of %s", key, value, c.Path, strings.Join(allowed, ", "))
+		}
+	}
+	return c, nil
+}
+
+// Save writes the config file.
+func (c *Config) Save() error {
+	f, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := c.files().MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
+		return err
+	}
+	return c.files().WriteFile(c.Path, f, 0644)
+}
+
+// Change returns the settings as Change of the config file without saving them; e.g. to
+// show them in dry-run mode.
+func (c *Config) Change() (Change, error) {
+	f, err := yaml.Marshal(c)
+	if err != nil {
+		return Change{}, err
+	}
+	old, err := c.files().ReadFile(c.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return Change{}, err
+	}
+	return Change{Path: c.Path, Old: old, New: f}, nil
+}
+
+func (c *Config) files() FileSystem {
+	if c.fs == nil {
+		return OSFileSystem{}
+	}
+	return c.fs
+}
+
+func (c *Config) field(key string) (interface{}, error) {
+	fields := map[string]interface{}{
+		"output":             &c.Output,
+		"binding":            &c.Binding,
+		"protected-contexts": &c.ProtectedContexts,
+		"hooks-dir":          &c.HooksDir,
+		"fallback-profile":   &c.FallbackProfile,
+		"list-columns":       &c.ListColumns,
+		"colors":             &c.Colors,
+		"prompt-format":      &c.PromptFormat,
+		"audit":              &c.Audit,
+		"safe-context":       &c.SafeContext,
+		"validate-namespace": &c.ValidateNamespace,
+	}
+	f, ok := fields[key]
+	if !ok {
+		return nil, fmt.Errorf("[CONFIG] unknown key '%s'; one of %s", key, strings.Join(ConfigKeys(), ", "))
+	}
+	return f, nil
+}
+
+// Get returns the value of the key in the format of its environment variable; lists are
+// comma separated.
+func (c *Config) Get(key string) string {
+	f, err := c.field(key)
+	if err != nil {
+		return ""
+	}
+	switch v := f.(type) {
+	case *string:
+		return *v
+	case *[]string:
+		return strings.Join(*v, ",")
+	case *bool:
+		if *v {
+			return "true"
+		}
+	}
+	return ""
+}
+
+// Set changes the value of the key. Lists are given comma separated and an empty value
+// removes the setting.
+func (c *Config) Set(key, value string) error {
+	f, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	if allowed, ok := configValues[key]; ok && len(value) > 0 && !inList(value, allowed) {
+		return fmt.Errorf("[CONFIG] invalid %s '%s'; one of %s", key, value, strings.Join(allowed, ", "))
+	}
+	switch v := f.(type) {
+	case *string:
+		*v = value
+	case *[]string:
+		*v = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); len(item) > 0 {
+				*v = append(*v, item)
+			}
+		}
+	case *bool:
+		if len(value) < 1 {
+			*v = false
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("[CONFIG] %s requires 'true' or 'false'", key)
+		}
+		*v = b
+	}
+	return nil
+}
+
+// Environ returns the settings, which are set in the config file, as environment variables.
+func (c *Config) Environ() map[string]string {
+	env := map[string]string{}
+	for key, name := range ConfigEnv {
+		if value := c.Get(key); len(value) > 0 {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// IsProtected reports whether the context is marked as protected in the kube config or
+// matches one of the globs of the setting protected-contexts.
+func (k *KubeConfig) IsProtected(ctx *KubeContext) bool {
+	if ctx.Protected {
+		return true
+	}
+	for _, p := range strings.Split(k.setting("protected-contexts"), ",") {
+		if p = strings.TrimSpace(p); len(p) > 0 && MatchGlob(p, ctx.Name) {
+			return true
+		}
+	}
+	return false
+}
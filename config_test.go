@@ -0,0 +1,114 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eksdefault-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("EKSDEFAULT_CONFIG", filepath.Join(dir, "config.yaml"))
+
+	c, err := ReadConfig()
+	if err != nil || len(c.Environ()) > 0 {
+		t.Fatalf("ReadConfig() without file = %+v, %v, want an empty config", c, err)
+	}
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{key: "output", value: "short"},
+		{key: "output", value: "json", wantErr: true},
+		{key: "binding", value: "shell"},
+		{key: "protected-contexts", value: "prod-*, live"},
+		{key: "colors", value: "live=red+bold,dev*=green"},
+		{key: "validate-namespace", value: "true"},
+		{key: "validate-namespace", value: "maybe", wantErr: true},
+		{key: "fallback-profile", value: "dev"},
+		{key: "fallback-profile", value: ""},
+		{key: "colour", value: "red", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := c.Set(tt.key, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("Set(%s, %s) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	c, err = ReadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"EKSDEFAULT_OUTPUT":             "short",
+		"EKSDEFAULT_BINDING":            "shell",
+		"EKSDEFAULT_PROTECTED_CONTEXTS": "prod-*,live",
+		"EKSDEFAULT_PROMPT_COLORS":      "live=red+bold,dev*=green",
+		"EKSDEFAULT_VALIDATE_NAMESPACE": "true",
+	}
+	got := c.Environ()
+	if len(got) != len(want) {
+		t.Errorf("Environ() = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("Environ()[%s] = %s, want %s", name, got[name], value)
+		}
+	}
+
+	for _, content := range []string{"colour: red\n", "binding: local\n"} {
+		if err := ioutil.WriteFile(c.Path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadConfig(); err == nil || !strings.Contains(err.Error(), c.Path) {
+			t.Errorf("ReadConfig() of %q error = %v, want the file named", content, err)
+		}
+	}
+}
+
+func TestKubeConfig_configured(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetContextTo("minikube"); err != NoProfilSet {
+		t.Errorf("SetContextTo() error = %v, want %v", err, NoProfilSet)
+	}
+	t.Setenv("EKSDEFAULT_FALLBACK_PROFILE", "dev")
+	if err := k.SetContextTo("minikube"); err != nil {
+		t.Errorf("SetContextTo() with fallback profile error = %v", err)
+	}
+
+	t.Setenv("EKSDEFAULT_PROTECTED_CONTEXTS", "cntx*")
+	ctx, _, _ := k.GetContextBy("cntxA")
+	if !k.IsProtected(ctx) {
+		t.Errorf("IsProtected(cntxA) = false, want true")
+	}
+	if err := k.SetContextTo("cntxA"); err != NotConfirmed {
+		t.Errorf("SetContextTo() of a context matching the protected patterns error = %v, want %v", err, NotConfirmed)
+	}
+}
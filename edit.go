@@ -0,0 +1,109 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// UpdateContext replaces the settings of the context by the ones of updated. A changed
+// cluster or user must exist inside the kube config and a changed aws-profile inside the AWS
+// credentials file. A changed name renames the context.
+func (k *KubeConfig) UpdateContext(contextName string, updated KubeContext) error {
+	old, idx, err := k.GetContextBy(contextName)
+	if err != nil {
+		return err
+	}
+	if updated.Context == nil {
+		updated.Context = &Context{}
+	}
+	if len(updated.Name) < 1 {
+		return fmt.Errorf("[EDIT] the name of the context must not be empty")
+	}
+	if updated.Name != old.Name {
+		if _, _, err := k.GetContextBy(updated.Name); err == nil {
+			return fmt.Errorf("[EDIT] a context named '%s' already exists", updated.Name)
+		}
+	}
+	if updated.Context.Cluster != old.Context.Cluster && !inList(updated.Context.Cluster, k.ClusterNames()) {
+		return fmt.Errorf("[EDIT] the cluster '%s' does not exist in the kube config", updated.Context.Cluster)
+	}
+	if updated.Context.User != old.Context.User && !inList(updated.Context.User, k.UserNames()) {
+		return fmt.Errorf("[EDIT] the user '%s' does not exist in the kube config", updated.Context.User)
+	}
+	if updated.AWSprofile != old.AWSprofile && len(updated.AWSprofile) > 0 {
+		awsfile, err := k.credentialsFile()
+		if err != nil {
+			return err
+		}
+		if !inList(updated.AWSprofile, awsfile.GetProfilesNames()) {
+			return fmt.Errorf("[EDIT] the profile '%s' does not exist in '%s'", updated.AWSprofile, awsfile.Path)
+		}
+	}
+	for key, value := range updated.Labels {
+		if err := checkLabel(key, value); err != nil {
+			return err
+		}
+	}
+	event := HookEvent{
+		Event:        HookProfile,
+		OldContext:   old.Name,
+		NewContext:   updated.Name,
+		OldProfile:   old.AWSprofile,
+		NewProfile:   updated.AWSprofile,
+		OldCluster:   old.Context.Cluster,
+		NewCluster:   updated.Context.Cluster,
+		OldNamespace: old.Context.Namespace,
+		NewNamespace: updated.Context.Namespace,
+	}
+	profileChanged := updated.AWSprofile != old.AWSprofile
+	if profileChanged {
+		if err := k.hook(HookPre, event); err != nil {
+			return err
+		}
+	}
+	k.Contexts[idx] = updated
+	if k.CurrentContext == old.Name {
+		k.CurrentContext = updated.Name
+	}
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	records := []AuditRecord{
+		{Action: ActionRename, Old: old.Name, New: updated.Name},
+		{Action: ActionSetCluster, Old: old.Context.Cluster, New: updated.Context.Cluster},
+		{Action: ActionSetUser, Old: old.Context.User, New: updated.Context.User},
+		{Action: ActionSetNamespace, Old: old.Context.Namespace, New: updated.Context.Namespace},
+		{Action: ActionSetProfile, Old: old.AWSprofile, New: updated.AWSprofile},
+		{Action: ActionProtect, Old: strconv.FormatBool(old.Protected), New: strconv.FormatBool(updated.Protected)},
+		{Action: ActionLabel, Old: FormatLabels(old.Labels), New: FormatLabels(updated.Labels)},
+	}
+	for _, r := range records {
+		if r.Old == r.New {
+			continue
+		}
+		r.Context = updated.Name
+		r.Profile = updated.AWSprofile
+		r.Account = k.AccountOf(&updated)
+		if err := k.audit(r); err != nil {
+			return err
+		}
+	}
+	if profileChanged {
+		return k.hook(HookPost, event)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"testing"
+)
+
+func TestKubeConfig_UpdateContext(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+
+	tests := []struct {
+		name        string
+		context     string
+		updated     KubeContext
+		wantErr     bool
+		wantCurrent string
+	}{
+		{
+			name:        "0positive - change cluster, user, namespace and profile",
+			context:     "cntxA",
+			updated:     KubeContext{Name: "cntxA", AWSprofile: "dev", Context: &Context{Cluster: "ava", User: "userB", Namespace: "x"}},
+			wantCurrent: "cntxB",
+		},
+		{
+			name:        "1positive - rename the current-context",
+			context:     "cntxB",
+			updated:     KubeContext{Name: "cntxBB", AWSprofile: "live", Context: &Context{Cluster: "clstrB", User: "userB", Namespace: "bbbbb"}},
+			wantCurrent: "cntxBB",
+		},
+		{
+			name:    "2negative - unknown cluster",
+			context: "cntxC",
+			updated: KubeContext{Name: "cntxC", AWSprofile: "dev", Context: &Context{Cluster: "unknown", User: "userC"}},
+			wantErr: true,
+		},
+		{
+			name:    "3negative - unknown user",
+			context: "cntxC",
+			updated: KubeContext{Name: "cntxC", AWSprofile: "dev", Context: &Context{Cluster: "clstrC", User: "unknown"}},
+			wantErr: true,
+		},
+		{
+			name:    "4negative - unknown profile",
+			context: "cntxC",
+			updated: KubeContext{Name: "cntxC", AWSprofile: "unknown", Context: &Context{Cluster: "clstrC", User: "userC"}},
+			wantErr: true,
+		},
+		{
+			name:    "5negative - rename to an existing context",
+			context: "cntxC",
+			updated: KubeContext{Name: "cntxA", AWSprofile: "dev", Context: &Context{Cluster: "clstrC", User: "userC"}},
+			wantErr: true,
+		},
+		{
+			name:    "6negative - unknown context",
+			context: "unknown",
+			updated: KubeContext{Name: "unknown"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := GetConfigFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := k.UpdateContext(tt.context, tt.updated); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			k, _ = GetConfigFile()
+			got, _, err := k.GetContextBy(tt.updated.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.AWSprofile != tt.updated.AWSprofile || *got.Context != *tt.updated.Context {
+				t.Errorf("UpdateContext() got = %+v %+v, want %+v %+v", got, got.Context, tt.updated, tt.updated.Context)
+			}
+			if k.CurrentContext != tt.wantCurrent {
+				t.Errorf("current-context = %s, want %s", k.CurrentContext, tt.wantCurrent)
+			}
+		})
+	}
+}
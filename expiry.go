@@ -0,0 +1,180 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const expiryFile = "expiry"
+
+// CallerExpiry is recorded in the history for switches back after an expired switch.
+const CallerExpiry = "expiry"
+
+var (
+	NoExpiry = errors.New("the current-context does not expire")
+)
+
+// Expiry describes a switch, which is reverted after a while. Until then Context stays the
+// current-context; afterwards RevertTo and RevertProfile are used again. An empty RevertTo
+// unsets the current-context and restores RevertProfile as default AWS profile; without
+// RevertProfile the default AWS profile is unset too. KubeConfig is the kube config the
+// switch was made in.
+type Expiry struct {
+	Context       string    `yaml:"context"`
+	Until         time.Time `yaml:"until"`
+	RevertTo      string    `yaml:"revert-to"`
+	RevertProfile string    `yaml:"revert-profile"`
+	KubeConfig    string    `yaml:"kubeconfig,omitempty"`
+}
+
+// Remaining returns the time left until the switch expires; zero if already expired.
+func (e *Expiry) Remaining() time.Duration {
+	d := time.Until(e.Until)
+	if d < 0 {
+		return 0
+	}
+	return d.Round(time.Second)
+}
+
+// ReadExpiry returns the expiry of the current switch. The options are the same as for
+// GetConfigFile.
+func ReadExpiry(opts ...Option) (*Expiry, error) {
+	return newKubeConfig(opts).readExpiry()
+}
+
+func (k *KubeConfig) readExpiry() (*Expiry, error) {
+	e := &Expiry{}
+	f, err := k.fs().ReadFile(k.statePath(expiryFile))
+	if os.IsNotExist(err) {
+		return e, NoExpiry
+	}
+	if err != nil {
+		return e, err
+	}
+	if err = yaml.Unmarshal(f, e); err != nil {
+		return e, err
+	}
+	if len(e.Context) < 1 {
+		return e, NoExpiry
+	}
+	return e, nil
+}
+
+func (k *KubeConfig) writeExpiry(e Expiry) error {
+	f, err := yaml.Marshal(&e)
+	if err != nil {
+		return err
+	}
+	return k.writeState(expiryFile, f)
+}
+
+func (k *KubeConfig) removeExpiry() error {
+	err := k.fs().Remove(k.statePath(expiryFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SetContextFor switches to the context like SetContextTo, but only for the given duration.
+// Afterwards RevertExpired switches to the safe context. Without a safe context the one
+// used before this switch is taken.
+func (k *KubeConfig) SetContextFor(contextName string, d time.Duration, safeContext string) error {
+	if d <= 0 {
+		return fmt.Errorf("the duration must be positive, got %v", d)
+	}
+	e := Expiry{Context: contextName, Until: time.Now().Add(d).UTC(), RevertTo: k.CurrentContext, KubeConfig: k.Path}
+	if abs, err := filepath.Abs(k.Path); err == nil {
+		e.KubeConfig = abs
+	}
+	if awsfile, err := k.credentialsFile(); err == nil {
+		e.RevertProfile = activeProfile(awsfile)
+	}
+	if len(safeContext) > 0 {
+		safe, _, err := k.GetContextBy(safeContext)
+		if err != nil {
+			return err
+		}
+		if len(safe.AWSprofile) < 1 {
+			return NoProfilSet
+		}
+		e.RevertTo, e.RevertProfile = safe.Name, safe.AWSprofile
+	}
+	if e.RevertTo == contextName {
+		return fmt.Errorf("the safe context must differ from '%s'", contextName)
+	}
+	if err := k.SetContextTo(contextName); err != nil || k.DryRun {
+		return err
+	}
+	return k.writeExpiry(e)
+}
+
+// RevertExpired switches back to the safe context, if the expiry of the current switch is
+// reached. It reports the expiry, which was reverted, or nil if nothing happened. The expiry
+// is dropped without a switch, if the current-context was changed outside of eksdefault.
+// The options are passed to GetConfigFile, but the kube config the switch was made in is
+// read, also while a pin is active.
+func RevertExpired(opts ...Option) (*Expiry, error) {
+	e, err := ReadExpiry(opts...)
+	if err == NoExpiry {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Before(e.Until) {
+		return nil, nil
+	}
+	var k *KubeConfig
+	if len(e.KubeConfig) > 0 {
+		k, err = GetConfigFile(append(opts, WithPath(e.KubeConfig))...)
+	} else {
+		k, err = GetUnpinnedConfigFile(opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+	if k.CurrentContext != e.Context {
+		return nil, k.removeExpiry()
+	}
+	k.Caller = CallerExpiry
+	if len(e.RevertTo) < 1 {
+		if err := k.UnSetDefault(); err != nil {
+			return nil, err
+		}
+		awsfile, err := k.credentialsFile()
+		if err != nil {
+			return nil, err
+		}
+		if err := k.setDefaultProfile(awsfile, e.RevertProfile); err != nil {
+			return nil, err
+		}
+		return e, k.removeExpiry()
+	}
+	// the safe context was chosen on purpose; do not ask again, if it is protected
+	k.ConfirmProtected = func(*KubeContext) error { return nil }
+	if err := k.switchContext(e.RevertTo, e.RevertProfile); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
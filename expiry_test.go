@@ -0,0 +1,226 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peterbueschel/awsdefault"
+)
+
+// expire moves the expiry of the current switch into the past.
+func expire(t *testing.T) {
+	e, err := ReadExpiry()
+	if err != nil {
+		t.Fatalf("ReadExpiry() error = %v", err)
+	}
+	e.Until = time.Now().Add(-time.Second)
+	if err := newKubeConfig(nil).writeExpiry(*e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKubeConfig_SetContextFor(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+
+	tests := []struct {
+		name        string
+		context     string
+		duration    time.Duration
+		safe        string
+		wantErr     bool
+		wantRevert  string
+		wantProfile string
+	}{
+		{
+			name:        "0positive - revert to the context used before",
+			context:     "cntxC",
+			duration:    time.Hour,
+			wantRevert:  "cntxB",
+			wantProfile: "live",
+		},
+		{
+			name:        "1positive - revert to a safe context",
+			context:     "cntxC",
+			duration:    time.Minute,
+			safe:        "cntxA",
+			wantRevert:  "cntxA",
+			wantProfile: "live",
+		},
+		{
+			name:     "2negative - safe context without aws profile",
+			context:  "cntxC",
+			duration: time.Minute,
+			safe:     "minikube",
+			wantErr:  true,
+		},
+		{
+			name:     "3negative - no duration",
+			context:  "cntxC",
+			duration: 0,
+			wantErr:  true,
+		},
+		{
+			name:     "4negative - safe context is the context itself",
+			context:  "cntxC",
+			duration: time.Minute,
+			safe:     "cntxC",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := GetConfigFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := k.SetContextTo("cntxB"); err != nil {
+				t.Fatal(err)
+			}
+			if err := k.SetContextFor(tt.context, tt.duration, tt.safe); (err != nil) != tt.wantErr {
+				t.Errorf("SetContextFor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			e, err := ReadExpiry()
+			if err != nil {
+				t.Fatalf("ReadExpiry() error = %v", err)
+			}
+			if e.Context != tt.context || e.RevertTo != tt.wantRevert || e.RevertProfile != tt.wantProfile {
+				t.Errorf("ReadExpiry() = %+v, want revert to %s with %s", e, tt.wantRevert, tt.wantProfile)
+			}
+			if r := e.Remaining(); r <= 0 || r > tt.duration {
+				t.Errorf("Remaining() = %v, want between 0 and %v", r, tt.duration)
+			}
+			// not expired yet
+			if reverted, err := RevertExpired(); err != nil || reverted != nil {
+				t.Errorf("RevertExpired() = %v, %v, want nothing reverted", reverted, err)
+			}
+			expire(t)
+			if reverted, err := RevertExpired(); err != nil || reverted == nil {
+				t.Fatalf("RevertExpired() = %v, %v, want reverted", reverted, err)
+			}
+			k, _ = GetConfigFile()
+			if k.CurrentContext != tt.wantRevert {
+				t.Errorf("current-context = %s, want %s", k.CurrentContext, tt.wantRevert)
+			}
+			awsfile, err := awsdefault.GetCredentialsFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p := activeProfile(awsfile); p != tt.wantProfile {
+				t.Errorf("default profile = %s, want %s", p, tt.wantProfile)
+			}
+			if _, err := ReadExpiry(); err != NoExpiry {
+				t.Errorf("ReadExpiry() error = %v, want %v", err, NoExpiry)
+			}
+		})
+	}
+}
+
+func TestRevertExpired(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a manual switch drops the expiry
+	if err := k.SetContextFor("cntxC", time.Minute, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetContextTo("cntxA"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadExpiry(); err != NoExpiry {
+		t.Errorf("ReadExpiry() after switch error = %v, want %v", err, NoExpiry)
+	}
+	// a change outside of eksdefault drops the expiry without a switch
+	if err := k.SetContextFor("cntxC", time.Minute, ""); err != nil {
+		t.Fatal(err)
+	}
+	expire(t)
+	k.CurrentContext = "cntxB"
+	if err := k.SaveContexts(); err != nil {
+		t.Fatal(err)
+	}
+	if reverted, err := RevertExpired(); err != nil || reverted != nil {
+		t.Errorf("RevertExpired() = %v, %v, want nothing reverted", reverted, err)
+	}
+	if _, err := ReadExpiry(); err != NoExpiry {
+		t.Errorf("ReadExpiry() error = %v, want %v", err, NoExpiry)
+	}
+	// a pinned kube config does not drop the expiry of the kube config the switch was made in
+	if err := k.SetContextFor("cntxC", time.Minute, ""); err != nil {
+		t.Fatal(err)
+	}
+	pinned := &KubeConfig{Contexts: k.Contexts, CurrentContext: "cntxA", Path: filepath.Join(StateDir(), "pinned.config")}
+	if err := pinned.SaveContexts(); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(pinned.Path)
+	expire(t)
+	if reverted, err := RevertExpired(WithPath(pinned.Path)); err != nil || reverted == nil {
+		t.Fatalf("RevertExpired() with a pinned kube config = %v, %v, want reverted", reverted, err)
+	}
+	if k, _ = GetConfigFile(); k.CurrentContext != "cntxB" {
+		t.Errorf("current-context = %s, want cntxB", k.CurrentContext)
+	}
+	// without a context to revert to, the current-context is unset and the default AWS
+	// profile of that time is restored
+	for _, profile := range []string{"dev", ""} {
+		if err := k.UnSetDefault(); err != nil {
+			t.Fatal(err)
+		}
+		awsfile, err := k.credentialsFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := k.setDefaultProfile(awsfile, profile); err != nil {
+			t.Fatal(err)
+		}
+		if err := k.SetContextFor("cntxC", time.Minute, ""); err != nil {
+			t.Fatal(err)
+		}
+		if e, _ := ReadExpiry(); e.RevertTo != "" || e.RevertProfile != profile {
+			t.Fatalf("ReadExpiry() = %+v, want no context and %q to revert to", e, profile)
+		}
+		expire(t)
+		if reverted, err := RevertExpired(); err != nil || reverted == nil {
+			t.Fatalf("RevertExpired() = %v, %v, want reverted", reverted, err)
+		}
+		if k, _ = GetConfigFile(); k.CurrentContext != "" {
+			t.Errorf("current-context = %s, want none", k.CurrentContext)
+		}
+		if awsfile, err = awsdefault.GetCredentialsFile(); err != nil {
+			t.Fatal(err)
+		}
+		if p := activeProfile(awsfile); p != profile {
+			t.Errorf("default profile = %q, want %q", p, profile)
+		}
+	}
+	// the last switch is recorded with its caller
+	entries, err := ReadHistory()
+	if err != nil || len(entries) < 1 || entries[len(entries)-1].Caller != CallerExpiry {
+		t.Errorf("ReadHistory() last entry caller is not %s", CallerExpiry)
+	}
+}
@@ -0,0 +1,120 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+)
+
+// flattened are the settings referencing files, which are embedded by Extract.
+var flattened = map[string]string{
+	"client-certificate": "client-certificate-data",
+	"client-key":         "client-key-data",
+}
+
+// Extract returns a self-contained kube config with only the given context, its cluster and
+// its user. Referenced certificate files are embedded and the aws-profile of the context is
+// set as AWS_PROFILE for the credential plugin of the user.
+func (k *KubeConfig) Extract(contextName string) (*KubeConfig, error) {
+	ctx, _, err := k.GetContextBy(contextName)
+	if err != nil {
+		return nil, err
+	}
+	cluster, _, err := k.GetClusterBy(ctx.Context.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := k.GetUserBy(ctx.Context.User)
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo := *cluster.Cluster
+	if len(clusterInfo.CertificateAuthority) > 0 {
+		data, err := k.fs().ReadFile(k.resolvePath(clusterInfo.CertificateAuthority))
+		if err != nil {
+			return nil, fmt.Errorf("[EXTRACT] unable to embed the certificate authority: %v", err)
+		}
+		clusterInfo.CertificateAuthority = ""
+		clusterInfo.CertificateAuthorityData = base64.StdEncoding.EncodeToString(data)
+	}
+	userInfo := UserInfo{Exec: user.User.Exec, Extra: map[string]interface{}{}}
+	for key, value := range user.User.Extra {
+		path, ok := value.(string)
+		if dataKey, found := flattened[key]; found && ok {
+			data, err := k.fs().ReadFile(k.resolvePath(path))
+			if err != nil {
+				return nil, fmt.Errorf("[EXTRACT] unable to embed the %s: %v", key, err)
+			}
+			userInfo.Extra[dataKey] = base64.StdEncoding.EncodeToString(data)
+			continue
+		}
+		userInfo.Extra[key] = value
+	}
+	if userInfo.Exec != nil && len(ctx.AWSprofile) > 0 {
+		exec := *userInfo.Exec
+		exec.Env = []ExecEnv{{Name: "AWS_PROFILE", Value: ctx.AWSprofile}}
+		for _, e := range userInfo.Exec.Env {
+			if e.Name != "AWS_PROFILE" {
+				exec.Env = append(exec.Env, e)
+			}
+		}
+		userInfo.Exec = &exec
+	}
+	extracted := &KubeConfig{
+		ApiVersion:     k.ApiVersion,
+		Kind:           k.Kind,
+		Preferences:    k.Preferences,
+		CurrentContext: ctx.Name,
+		Contexts: []KubeContext{{
+			Name:       ctx.Name,
+			AWSprofile: ctx.AWSprofile,
+			Context:    &Context{Cluster: cluster.Name, User: user.Name, Namespace: ctx.Context.Namespace},
+		}},
+		Clusters: []KubeCluster{{Name: cluster.Name, Cluster: &clusterInfo}},
+		Users:    []KubeUser{{Name: user.Name, User: &userInfo}},
+	}
+	if len(extracted.ApiVersion) < 1 {
+		extracted.ApiVersion = "v1"
+	}
+	if len(extracted.Kind) < 1 {
+		extracted.Kind = "Config"
+	}
+	return extracted, nil
+}
+
+// resolvePath returns the path of a file referenced by the kube config. Like kubectl, relative
+// paths are taken relative to the directory of the kube config.
+func (k *KubeConfig) resolvePath(path string) string {
+	if len(path) < 1 || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(k.Path), path)
+}
+
+// ExtractTo writes the kube config returned by Extract to the given path. The file is only
+// readable by the owner, because it contains credentials.
+func (k *KubeConfig) ExtractTo(contextName, path string) error {
+	extracted, err := k.Extract(contextName)
+	if err != nil {
+		return err
+	}
+	content, err := extracted.Content()
+	if err != nil {
+		return err
+	}
+	return k.writeFile(path, content, 0600)
+}
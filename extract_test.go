@@ -0,0 +1,94 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestKubeConfig_ExtractTo(t *testing.T) {
+	path, teardown := setupEKSConfig(t)
+	defer teardown()
+	dir := filepath.Dir(path)
+	prod := "arn:aws:eks:eu-west-1:123456789012:cluster/prod"
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("ca")})
+	if err := ioutil.WriteFile(filepath.Join(dir, "ca.crt"), cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetClusterCA(prod, filepath.Join(dir, "ca.crt"), false); err != nil {
+		t.Fatal(err)
+	}
+	u, idx, _ := k.GetUserBy(prod)
+	exec := *u.User.Exec
+	exec.Env = []ExecEnv{{Name: "AWS_PROFILE", Value: "old"}, {Name: "AWS_REGION", Value: "eu-west-1"}}
+	if err := k.SetUserExec(prod, exec); err != nil {
+		t.Fatal(err)
+	}
+	// relative to the directory of the kube config like kubectl does
+	k.Users[idx].User.Extra = map[string]interface{}{"client-key": "ca.crt"}
+	if err := k.AddContext(KubeContext{Name: "dangling", Context: &Context{Cluster: "unknown", User: prod}}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "extracted")
+	if err := k.ExtractTo("dangling", out); err == nil {
+		t.Errorf("ExtractTo() of a context with unknown cluster, want error")
+	}
+	if err := k.ExtractTo("prod", out); err != nil {
+		t.Fatalf("ExtractTo() error = %v", err)
+	}
+	if fi, err := os.Stat(out); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("ExtractTo() file mode = %v, %v, want 0600", fi.Mode(), err)
+	}
+	content, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &KubeConfig{}
+	if err := yaml.Unmarshal(content, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.CurrentContext != "prod" || len(got.Contexts) != 1 || len(got.Clusters) != 1 || len(got.Users) != 1 {
+		t.Fatalf("ExtractTo() wrote %s", content)
+	}
+	data := base64.StdEncoding.EncodeToString(cert)
+	if c := got.Clusters[0].Cluster; c.CertificateAuthority != "" || c.CertificateAuthorityData != data {
+		t.Errorf("cluster = %+v, want the embedded certificate authority", c)
+	}
+	user := got.Users[0].User
+	if user.Extra["client-key-data"] != data || user.Extra["client-key"] != nil {
+		t.Errorf("user = %+v, want the embedded client key", user.Extra)
+	}
+	wantEnv := []ExecEnv{{Name: "AWS_PROFILE", Value: "live"}, {Name: "AWS_REGION", Value: "eu-west-1"}}
+	if len(user.Exec.Env) != 2 || user.Exec.Env[0] != wantEnv[0] || user.Exec.Env[1] != wantEnv[1] {
+		t.Errorf("exec env = %+v, want %+v", user.Exec.Env, wantEnv)
+	}
+	// the original kube config is unchanged
+	if u, _, _ := k.GetUserBy(prod); u.User.Exec.Env[0].Value != "old" {
+		t.Errorf("ExtractTo() changed the original user")
+	}
+}
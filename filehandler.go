@@ -17,13 +17,11 @@ package eksdefault
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"runtime"
+	"regexp"
 	"sort"
+	"strconv"
+	"time"
 
-	"github.com/peterbueschel/awsdefault"
 	"gopkg.in/yaml.v2"
 )
 
@@ -32,15 +30,24 @@ var (
 		"no AWS profile configured for this context. " +
 			"Define it by adding 'aws-profile: <profile>' to the context inside the kube config",
 	)
+	NotConfirmed = errors.New(
+		"the context is protected and switching to it was not confirmed",
+	)
 )
 
+var eksARN = regexp.MustCompile(`^arn:aws[a-z-]*:eks:([a-z0-9-]+):([0-9]{12}):cluster/(.+)$`)
+
 type (
 	// Profile stored in the AWS shared credentials file consisting of an
 	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
 	KubeContext struct {
 		Name       string `yaml:"name"`
 		AWSprofile string `yaml:"aws-profile"`
-		*Context   `yaml:"context"`
+		// Protected contexts require a confirmation before switching to them.
+		Protected bool `yaml:"protected,omitempty"`
+		// Labels organize the contexts; they are selected via label selectors.
+		Labels   map[string]string `yaml:"labels,omitempty"`
+		*Context `yaml:"context"`
 	}
 	Context struct {
 		Cluster   string `yaml:"cluster"`
@@ -55,15 +62,36 @@ type (
 		Preferences    interface{}   `yaml:"preferences"`
 		Contexts       []KubeContext `yaml:"contexts"`
 		CurrentContext string        `yaml:"current-context"`
-		Clusters       []struct {
-			Name    string      `yaml:"name"`
-			Cluster interface{} `yaml:"cluster"`
-		} `yaml:"clusters"`
-		Users []struct {
-			Name string      `yaml:"name"`
-			User interface{} `yaml:"user"`
-		} `yaml:"users"`
-		Path string `yaml:"-"`
+		Clusters       []KubeCluster `yaml:"clusters"`
+		Users          []KubeUser    `yaml:"users"`
+		Path           string        `yaml:"-"`
+		// Caller names the part of eksdefault, which changes the kube config; one of the
+		// Caller* constants. It is recorded in the history.
+		Caller string `yaml:"-"`
+		// ConfirmProtected is asked before switching to a protected context. The switch is
+		// aborted, if it is not set or returns an error.
+		ConfirmProtected func(ctx *KubeContext) error `yaml:"-"`
+		// Audit receives a record of every change. If not set, the sink configured via
+		// EKSDEFAULT_AUDIT is used once created and kept until Close.
+		Audit AuditSink `yaml:"-"`
+		// Hooks runs the hooks before and after switching or unsetting the current-context
+		// and changing the aws-profile of a context. If not set, the executables inside
+		// HooksDir are used.
+		Hooks HookRunner `yaml:"-"`
+		// DryRun collects the new contents of the files in Changes instead of writing them.
+		// Nothing is recorded in the state directory or the audit log.
+		DryRun  bool     `yaml:"-"`
+		Changes []Change `yaml:"-"`
+		// CredentialsPath and ConfigPath are the AWS credentials file and the config file of
+		// eksdefault; see WithCredentialsPath and WithConfigPath. StateDir replaces the
+		// directory returned by StateDir; see WithStateDir.
+		CredentialsPath string `yaml:"-"`
+		ConfigPath      string `yaml:"-"`
+		StateDir        string `yaml:"-"`
+		// FS reads and writes the files; the OSFileSystem if not set.
+		FS        FileSystem `yaml:"-"`
+		settings  *Config
+		auditSink AuditSink
 	}
 )
 
@@ -76,41 +104,44 @@ func inList(item string, list []string) bool {
 	return false
 }
 
-// GetCredentialsFile reads the AWS credentials file either from the HOME directory or
-// from a path given by the environment variable AWS_SHARED_CREDENTIALS_FILE
-func GetConfigFile() (*KubeConfig, error) {
-	home := func() string {
-		if runtime.GOOS == "windows" {
-			return os.Getenv("USERPROFILE")
-		}
-		return os.Getenv("HOME")
-	}
-	path := filepath.Join(home(), ".kube", "config")
-	if p := os.Getenv("KUBECONFIG"); len(p) > 0 {
-		path = p
-	}
-	k := &KubeConfig{Path: path}
-	f, err := ioutil.ReadFile(path)
+// GetConfigFile reads the kube config either from the HOME directory or from a path given by
+// the environment variable KUBECONFIG. The options change the paths and the FileSystem.
+func GetConfigFile(opts ...Option) (*KubeConfig, error) {
+	k := newKubeConfig(opts)
+	return k, k.read()
+}
+
+// read reads and validates the kube config at k.Path.
+func (k *KubeConfig) read() error {
+	f, err := k.fs().ReadFile(k.Path)
 	if err != nil {
-		return k, err
+		return err
 	}
-	err = yaml.Unmarshal(f, &k)
-	if err != nil {
-		return k, err
+	if err = yaml.Unmarshal(f, k); err != nil {
+		return err
 	}
+	if err = k.checkDuplicates(); err != nil {
+		*k = KubeConfig{}
+		return err
+	}
+	sort.Slice(k.Contexts, func(i, j int) bool {
+		return k.Contexts[i].Name < k.Contexts[j].Name
+	})
+	return nil
+}
+
+// checkDuplicates returns an error, if a context name is used more than once.
+func (k *KubeConfig) checkDuplicates() error {
 	dupl := make(map[string]int)
 	for _, ctx := range k.Contexts {
 		dupl[ctx.Name]++
 	}
 	for d, v := range dupl {
 		if v > 1 {
-			return &KubeConfig{}, fmt.Errorf("[KUBECONFIG] found duplicated context name: %s", d)
+			return fmt.Errorf("[KUBECONFIG] found duplicated context name: %s", d)
 		}
 	}
-	sort.Slice(k.Contexts, func(i, j int) bool {
-		return k.Contexts[i].Name < k.Contexts[j].Name
-	})
-	return k, nil
+	return nil
 }
 
 // GetProfilesNames returns a sorted list of all available profiles inside the AWS credentials file.
@@ -122,6 +153,29 @@ func (k *KubeConfig) GetContextNames() (names []string) {
 	return
 }
 
+// GetNamespaces returns a sorted list of all namespaces used by the contexts of the kube config.
+func (k *KubeConfig) GetNamespaces() (namespaces []string) {
+	for _, c := range k.Contexts {
+		if c.Context == nil || len(c.Context.Namespace) < 1 {
+			continue
+		}
+		if !inList(c.Context.Namespace, namespaces) {
+			namespaces = append(namespaces, c.Context.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return
+}
+
+// GetProfileNames returns a sorted list of all profiles available inside the AWS credentials file.
+func GetProfileNames(opts ...Option) ([]string, error) {
+	awsfile, err := newKubeConfig(opts).credentialsFile()
+	if err != nil {
+		return nil, err
+	}
+	return awsfile.GetProfilesNames(), nil
+}
+
 // GetProfileBy returns the profile by a given name
 func (k *KubeConfig) GetContextBy(name string) (*KubeContext, int, error) {
 	for idx, p := range k.Contexts {
@@ -135,35 +189,113 @@ func (k *KubeConfig) GetContextBy(name string) (*KubeContext, int, error) {
 	return &KubeContext{}, -1, fmt.Errorf("[GETCONTEXT] cannot find context named '%s'", name)
 }
 
-func (k *KubeConfig) SaveContexts() error {
+// Content returns the kube config as it would be saved.
+func (k *KubeConfig) Content() ([]byte, error) {
 	sort.Slice(k.Contexts, func(i, j int) bool {
 		return k.Contexts[i].Name < k.Contexts[j].Name
 	})
-	cnf, err := yaml.Marshal(&k)
+	return yaml.Marshal(&k)
+}
+
+func (k *KubeConfig) SaveContexts() error {
+	cnf, err := k.Content()
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(k.Path, cnf, 0644)
+	return k.writeFile(k.Path, cnf, 0644)
 }
 
+// SetContextTo changes the current-context and sets the AWS profile of this context as
+// default profile inside the AWS credentials file.
 func (k *KubeConfig) SetContextTo(contextName string) error {
+	return k.switchContext(contextName, "")
+}
+
+// switchContext changes the current-context and the default AWS profile. If profile is empty,
+// the aws-profile of the context or the FallbackProfile is used. Every switch is recorded in the
+// history.
+func (k *KubeConfig) switchContext(contextName, profile string) error {
 	ctx, _, err := k.GetContextBy(contextName)
 	if err != nil {
 		return err
 	}
-	if len(ctx.AWSprofile) < 1 {
+	if len(profile) < 1 {
+		profile = ctx.AWSprofile
+	}
+	if len(profile) < 1 {
+		profile = k.setting("fallback-profile")
+	}
+	if len(profile) < 1 {
 		return NoProfilSet
 	}
-	awsfile, err := awsdefault.GetCredentialsFile()
+	if k.IsProtected(ctx) && !k.DryRun {
+		if k.ConfirmProtected == nil {
+			return NotConfirmed
+		}
+		if err = k.ConfirmProtected(ctx); err != nil {
+			return err
+		}
+	}
+	awsfile, err := k.credentialsFile()
 	if err != nil {
 		return err
 	}
-	err = awsfile.SetDefaultTo(ctx.AWSprofile)
+	prev := Previous{Context: k.CurrentContext, Profile: activeProfile(awsfile)}
+	oldCluster, oldNamespace := k.contextInfo(prev.Context)
+	event := HookEvent{
+		Event:        HookSwitch,
+		OldContext:   prev.Context,
+		NewContext:   contextName,
+		OldProfile:   prev.Profile,
+		NewProfile:   profile,
+		OldCluster:   oldCluster,
+		NewCluster:   ctx.Context.Cluster,
+		OldNamespace: oldNamespace,
+		NewNamespace: ctx.Context.Namespace,
+	}
+	if err = k.hook(HookPre, event); err != nil {
+		return err
+	}
+	err = k.setDefaultProfile(awsfile, profile)
 	if err != nil {
 		return err
 	}
+	now := time.Now().UTC()
 	k.CurrentContext = contextName
-	return k.SaveContexts()
+	if err = k.SaveContexts(); err != nil || k.DryRun {
+		return err
+	}
+	if err = k.removeExpiry(); err != nil {
+		return err
+	}
+	if prev.Context != contextName {
+		if err = k.writePrevious(prev); err != nil {
+			return err
+		}
+	}
+	err = k.appendHistory(HistoryEntry{
+		Time:    now,
+		From:    prev.Context,
+		To:      contextName,
+		Profile: profile,
+		Caller:  k.Caller,
+	})
+	if err != nil {
+		return err
+	}
+	err = k.audit(AuditRecord{
+		Action:     ActionSetContext,
+		Context:    contextName,
+		Old:        prev.Context,
+		New:        contextName,
+		OldProfile: prev.Profile,
+		Profile:    profile,
+		Account:    k.AccountOf(ctx),
+	})
+	if err != nil {
+		return err
+	}
+	return k.hook(HookPost, event)
 }
 
 // AddProfileTo
@@ -173,31 +305,150 @@ func (k *KubeConfig) AddProfileTo(contextName, profileName string) error {
 		return err
 	}
 	// check if profile exsists in AWS Credentials file
-	awsfile, err := awsdefault.GetCredentialsFile()
+	awsfile, err := k.credentialsFile()
 	if err != nil {
 		return err
 	}
 	if inList(profileName, awsfile.GetProfilesNames()) {
+		old := ctx.AWSprofile
+		event := HookEvent{
+			Event:        HookProfile,
+			OldContext:   contextName,
+			NewContext:   contextName,
+			OldProfile:   old,
+			NewProfile:   profileName,
+			OldCluster:   ctx.Context.Cluster,
+			NewCluster:   ctx.Context.Cluster,
+			OldNamespace: ctx.Context.Namespace,
+			NewNamespace: ctx.Context.Namespace,
+		}
+		if err := k.hook(HookPre, event); err != nil {
+			return err
+		}
 		ctx.AWSprofile = profileName
 		k.Contexts[idx] = *ctx
-		return k.SaveContexts()
+		if err := k.SaveContexts(); err != nil {
+			return err
+		}
+		err := k.audit(AuditRecord{
+			Action:  ActionSetProfile,
+			Context: contextName,
+			Old:     old,
+			New:     profileName,
+			Profile: profileName,
+			Account: k.AccountOf(ctx),
+		})
+		if err != nil {
+			return err
+		}
+		return k.hook(HookPost, event)
 	}
 	return fmt.Errorf("given profile name '%s' does not exists in '%s'", profileName, awsfile.Path)
 }
 
-// AddProfileTo
+// AddNamespaceTo changes the namespace of the context. The old namespace is remembered for
+// SetPreviousNamespace.
 func (k *KubeConfig) AddNamespaceTo(contextName, namespace string) error {
 	ctx, idx, err := k.GetContextBy(contextName)
 	if err != nil {
 		return err
 	}
+	old := ctx.Context.Namespace
 	ctx.Context.Namespace = namespace
 	k.Contexts[idx] = *ctx
-	return k.SaveContexts()
+	if err := k.SaveContexts(); err != nil || k.DryRun {
+		return err
+	}
+	if len(old) > 0 && old != namespace {
+		if err := k.rememberNamespace(contextName, old); err != nil {
+			return err
+		}
+	}
+	return k.audit(AuditRecord{
+		Action:  ActionSetNamespace,
+		Context: contextName,
+		Old:     old,
+		New:     namespace,
+		Profile: ctx.AWSprofile,
+		Account: k.AccountOf(ctx),
+	})
 }
 
-// UnSetDefault deletes the default section inside the AWS credentials file.
+// AddContext appends the new context to the kube config.
+func (k *KubeConfig) AddContext(ctx KubeContext) error {
+	if _, _, err := k.GetContextBy(ctx.Name); err == nil {
+		return fmt.Errorf("[ADDCONTEXT] a context named '%s' already exists", ctx.Name)
+	}
+	if ctx.Context == nil {
+		ctx.Context = &Context{}
+	}
+	k.Contexts = append(k.Contexts, ctx)
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{
+		Action:  ActionAddContext,
+		Context: ctx.Name,
+		New:     ctx.Name,
+		Profile: ctx.AWSprofile,
+		Account: k.AccountOf(&ctx),
+	})
+}
+
+// SetProtected marks the context as protected or removes the mark.
+func (k *KubeConfig) SetProtected(contextName string, protected bool) error {
+	_, idx, err := k.GetContextBy(contextName)
+	if err != nil {
+		return err
+	}
+	old := k.Contexts[idx].Protected
+	k.Contexts[idx].Protected = protected
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{
+		Action:  ActionProtect,
+		Context: contextName,
+		Old:     strconv.FormatBool(old),
+		New:     strconv.FormatBool(protected),
+	})
+}
+
+// AccountOf returns the AWS account ID of the EKS cluster used by the context. It is taken
+// from the cluster ARN either used as cluster name or as context name, which is the default
+// of 'aws eks update-kubeconfig'. An empty string is returned, if no ARN is found.
+func (k *KubeConfig) AccountOf(ctx *KubeContext) string {
+	for _, name := range []string{ctx.Context.Cluster, ctx.Name} {
+		if m := eksARN.FindStringSubmatch(name); m != nil {
+			return m[2]
+		}
+	}
+	return ""
+}
+
+// UnSetDefault deletes the current-context entry inside the kube config.
 func (k *KubeConfig) UnSetDefault() error {
+	from := k.CurrentContext
+	cluster, namespace := k.contextInfo(from)
+	event := HookEvent{Event: HookUnset, OldContext: from, OldCluster: cluster, OldNamespace: namespace}
+	if ctx, _, err := k.GetContextBy(from); err == nil {
+		event.OldProfile = ctx.AWSprofile
+	}
+	if err := k.hook(HookPre, event); err != nil {
+		return err
+	}
 	k.CurrentContext = ""
-	return k.SaveContexts()
+	if err := k.SaveContexts(); err != nil || k.DryRun {
+		return err
+	}
+	if err := k.removeExpiry(); err != nil {
+		return err
+	}
+	if err := k.appendHistory(HistoryEntry{Time: time.Now().UTC(), From: from, Caller: k.Caller}); err != nil {
+		return err
+	}
+	if err := k.audit(AuditRecord{Action: ActionUnsetContext, Context: from, Old: from}); err != nil {
+		return err
+	}
+	return k.hook(HookPost, event)
 }
@@ -15,55 +15,54 @@
 package eksdefault
 
 import (
-	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
-
-	"github.com/peterbueschel/awsdefault"
 )
 
-var (
-	testFileContent = []byte("")
-	testFilePath    = "testdata/config"
+var testFileContent = []byte("")
+
+const (
+	memKubeConfigPath  = "/home/.kube/config"
+	memCredentialsPath = "/home/.aws/credentials"
+	memStateDir        = "/home/.local/state/eksdefault"
 )
 
 func TestMain(m *testing.M) {
-	// setup
 	var err error
 	testFileContent, err = ioutil.ReadFile("testdata/.kube/config")
 	if err != nil {
 		log.Fatal(err)
-		os.Exit(1)
-	}
-	code := m.Run()
-	// teardown
-	err = os.Remove(testFilePath)
-	if err != nil {
-		log.Fatal(err)
-		os.Exit(1)
-	}
-	if err := os.Setenv("HOME", "testdata"); err != nil {
-		log.Fatal(err)
-		os.Exit(1)
 	}
-	awsfile, err := awsdefault.GetCredentialsFile()
+	os.Exit(m.Run())
+}
+
+// isolateState keeps the state and the config of eksdefault in a temporary directory for the
+// tests, which use the default locations.
+func isolateState(t testing.TB) {
+	dir, err := ioutil.TempDir("", "eksdefault-state")
 	if err != nil {
-		log.Fatal(err)
-		os.Exit(1)
-	}
-	if err = awsfile.UnSetDefault(); err != nil {
-		log.Fatal(err)
-		os.Exit(1)
-	}
-	//
-	if err = ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
-		log.Fatal(err)
-		os.Exit(1)
+		t.Fatal(err)
 	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Setenv("EKSDEFAULT_STATE_DIR", filepath.Join(dir, "state"))
+	t.Setenv("EKSDEFAULT_CONFIG_DIR", filepath.Join(dir, "config"))
+}
 
-	os.Exit(code)
+// memFiles returns an in-memory copy of the test kube config and, if wanted, of the test
+// AWS credentials file, so that the tests do not share files or environment variables.
+func memFiles(t *testing.T, withCredentials bool) *MemFileSystem {
+	files := map[string][]byte{memKubeConfigPath: testFileContent}
+	if withCredentials {
+		creds, err := ioutil.ReadFile("testdata/.aws/credentials")
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[memCredentialsPath] = creds
+	}
+	return NewMemFileSystem(files)
 }
 
 func TestGetConfigFile(t *testing.T) {
@@ -139,10 +138,9 @@ func TestGetConfigFile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := os.Setenv(tt.envVar, tt.envVal); err != nil {
-				t.Errorf("GetConfigFile() error = %v", err)
-				return
-			}
+			t.Setenv("HOME", "testdata")
+			t.Setenv("KUBECONFIG", "")
+			t.Setenv(tt.envVar, tt.envVal)
 			got, err := GetConfigFile()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetConfigFile() error = %v, wantErr %v", err, tt.wantErr)
@@ -226,6 +224,46 @@ func TestKubeConfig_GetContextNames(t *testing.T) {
 	}
 }
 
+func TestKubeConfig_GetNamespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		contexts []KubeContext
+		want     []string
+	}{
+		{
+			name: "0positiv - unique and sorted namespaces",
+			contexts: []KubeContext{
+				KubeContext{Name: "cntxA", Context: &Context{Namespace: "b"}},
+				KubeContext{Name: "cntxB", Context: &Context{Namespace: "a"}},
+				KubeContext{Name: "cntxC", Context: &Context{Namespace: "b"}},
+				KubeContext{Name: "minikube"},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name:     "1positiv - no namespaces",
+			contexts: []KubeContext{KubeContext{Name: "minikube", Context: &Context{}}},
+			want:     []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &KubeConfig{Contexts: tt.contexts}
+			got := k.GetNamespaces()
+			if len(got) != len(tt.want) {
+				t.Errorf("KubeConfig.GetNamespaces() = %v, want %v", got, tt.want)
+				return
+			}
+			for idx, n := range got {
+				if tt.want[idx] != n {
+					t.Errorf("KubeConfig.GetNamespaces() = %v, want %v", got, tt.want)
+					return
+				}
+			}
+		})
+	}
+}
+
 func TestKubeConfig_GetContextBy(t *testing.T) {
 	type fields struct {
 		Contexts       []KubeContext
@@ -311,26 +349,23 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 	type fields struct {
 		Contexts       []KubeContext
 		CurrentContext string
-		Path           string
 	}
 	type args struct {
 		contextName string
 	}
 	tests := []struct {
-		name        string
-		fields      fields
-		args        args
-		wantErr     bool
-		wantCtx     string
-		wantProfile string
-		envVar      string
-		envVal      string
-		setDefault  string
+		name          string
+		fields        fields
+		args          args
+		wantErr       bool
+		wantCtx       string
+		wantProfile   string
+		setDefault    string
+		noCredentials bool
 	}{
 		{
 			name: "0positive - change current context",
 			fields: fields{
-				Path:           testFilePath,
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: "live"},
@@ -343,13 +378,10 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 			wantErr:     false,
 			wantCtx:     "cntxA",
 			wantProfile: "live",
-			envVar:      "HOME", // for awsdefault
-			envVal:      "testdata",
 		},
 		{
 			name: "1negative - context not found but profile and current context keep the same",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: "live"},
@@ -362,14 +394,11 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 			wantErr:     true,
 			wantCtx:     "cntxB",
 			wantProfile: "dev",
-			envVar:      "HOME", // for awsdefault
-			envVal:      "testdata",
 			setDefault:  "dev",
 		},
 		{
 			name: "2negative - no aws credentials file found, also not context set",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: "live"},
@@ -378,17 +407,15 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 					KubeContext{Name: "minikube", AWSprofile: ""},
 				},
 			},
-			args:        args{"cntxC"},
-			wantErr:     true,
-			wantCtx:     "cntxB",
-			wantProfile: "",
-			envVar:      "HOME",           // for awsdefault and kube config
-			envVal:      "testdata/byEnv", // only kube config file is there, no aws file
+			args:          args{"cntxC"},
+			wantErr:       true,
+			wantCtx:       "cntxB",
+			wantProfile:   "",
+			noCredentials: true,
 		},
 		{
 			name: "3negative - no aws profile found for context, nothing should changed",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: "live"},
@@ -401,13 +428,10 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 			wantErr:     true,
 			wantCtx:     "cntxB",
 			wantProfile: "no default",
-			envVar:      "HOME", // for awsdefault and kube config
-			envVal:      "testdata",
 		},
 		{
 			name: "4negative - unknown AWS profile configured, nothing should changed",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: "unknown"},
@@ -420,13 +444,10 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 			wantErr:     true,
 			wantCtx:     "cntxB",
 			wantProfile: "no default",
-			envVar:      "HOME", // for awsdefault and kube config
-			envVal:      "testdata",
 		},
 		{
 			name: "5positive - unknown AWS profile for the current context configured, but new context has known aws profile.",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: "live"},
@@ -439,43 +460,38 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 			wantErr:     false,
 			wantCtx:     "cntxC",
 			wantProfile: "dev",
-			envVar:      "HOME", // for awsdefault and kube config
-			envVal:      "testdata",
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
 			//set up
-			if err := os.Setenv(tt.envVar, tt.envVal); err != nil {
-				t.Errorf("SetContextTo() error = %v", err)
-				return
+			fs := memFiles(t, !tt.noCredentials)
+			k := &KubeConfig{
+				Contexts:        tt.fields.Contexts,
+				CurrentContext:  tt.fields.CurrentContext,
+				Path:            memKubeConfigPath,
+				CredentialsPath: memCredentialsPath,
+				StateDir:        memStateDir,
+				FS:              fs,
 			}
 			if len(tt.setDefault) > 0 {
-				preAwsfile, err := awsdefault.GetCredentialsFile()
+				preAwsfile, err := k.credentialsFile()
 				if err != nil {
 					t.Errorf("KubeConfig.SetContextTo() error read result credentials file = %v", err)
 					return
 				}
-				if err := preAwsfile.SetDefaultTo(tt.setDefault); err != nil {
+				if err := k.setDefaultProfile(preAwsfile, tt.setDefault); err != nil {
 					t.Errorf("SetContextTo() setDefault error = %v", err)
 					return
 				}
 			}
-
-			k := &KubeConfig{
-				Contexts:       tt.fields.Contexts,
-				CurrentContext: tt.fields.CurrentContext,
-				Path:           tt.fields.Path,
-			}
 			if err := k.SetContextTo(tt.args.contextName); (err != nil) != tt.wantErr {
 				t.Errorf("KubeConfig.SetContextTo() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if err := os.Setenv("KUBECONFIG", k.Path); err != nil {
-				t.Errorf("SetContextTo() error = %v", err)
-				return
-			}
-			r, err := GetConfigFile()
+			r, err := GetConfigFile(WithPath(memKubeConfigPath), WithCredentialsPath(memCredentialsPath), WithFileSystem(fs))
 			if err != nil {
 				t.Errorf("KubeConfig.SetContextTo() error read result config file = %v", err)
 				return
@@ -483,8 +499,14 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 			if r.CurrentContext != tt.wantCtx {
 				t.Errorf("KubeConfig.SetContextTo() got = %v, want = %v", r.CurrentContext, tt.wantCtx)
 			}
+			if !tt.wantErr {
+				p, err := ReadPrevious(WithFileSystem(fs), WithStateDir(memStateDir))
+				if err != nil || p.Context != tt.fields.CurrentContext {
+					t.Errorf("KubeConfig.SetContextTo() previous = %+v, %v, want %v", p, err, tt.fields.CurrentContext)
+				}
+			}
 			if len(tt.wantProfile) > 0 {
-				awsfile, err := awsdefault.GetCredentialsFile()
+				awsfile, err := r.credentialsFile()
 				if err != nil {
 					t.Errorf("KubeConfig.SetContextTo() error read result credentials file = %v", err)
 					return
@@ -496,14 +518,7 @@ func TestKubeConfig_SetContextTo(t *testing.T) {
 				if n != tt.wantProfile {
 					t.Errorf("KubeConfig.SetContextTo() wrong aws profile. Got = %v, want = %v", n, tt.wantProfile)
 				}
-
-				// teardown
-				if err = awsfile.UnSetDefault(); err != nil {
-					t.Errorf("KubeConfig.SetContextTo() error unset aws default profile = %v", err)
-					return
-				}
 			}
-
 		})
 	}
 }
@@ -512,24 +527,21 @@ func TestKubeConfig_AddProfileTo(t *testing.T) {
 	type fields struct {
 		Contexts       []KubeContext
 		CurrentContext string
-		Path           string
 	}
 	type args struct {
 		contextName string
 		profileName string
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
-		envVar  string
-		envVal  string
+		name          string
+		fields        fields
+		args          args
+		wantErr       bool
+		noCredentials bool
 	}{
 		{
 			name: "0positive - add a profile to the context",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: ""},
@@ -540,13 +552,10 @@ func TestKubeConfig_AddProfileTo(t *testing.T) {
 			},
 			args:    args{contextName: "cntxA", profileName: "dev"},
 			wantErr: false,
-			envVar:  "HOME", // for awsdefault and kube config
-			envVal:  "testdata",
 		},
 		{
 			name: "1negative - try to add a none existing profile",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: ""},
@@ -557,13 +566,10 @@ func TestKubeConfig_AddProfileTo(t *testing.T) {
 			},
 			args:    args{contextName: "cntxA", profileName: "xxxxx"},
 			wantErr: true,
-			envVar:  "HOME", // for awsdefault and kube config
-			envVal:  "testdata",
 		},
 		{
 			name: "2negative - try to change a none existing context",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: ""},
@@ -574,13 +580,10 @@ func TestKubeConfig_AddProfileTo(t *testing.T) {
 			},
 			args:    args{contextName: "xxxxx", profileName: "dev"},
 			wantErr: true,
-			envVar:  "HOME", // for awsdefault and kube config
-			envVal:  "testdata",
 		},
 		{
 			name: "3negative - try to read a none existing aws credentials file",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: ""},
@@ -589,54 +592,43 @@ func TestKubeConfig_AddProfileTo(t *testing.T) {
 					KubeContext{Name: "minikube", AWSprofile: ""},
 				},
 			},
-			args:    args{contextName: "cntxC", profileName: "dev"},
-			wantErr: true,
-			envVar:  "HOME", // for awsdefault and kube config
-			envVal:  "xxxxxxx",
+			args:          args{contextName: "cntxC", profileName: "dev"},
+			wantErr:       true,
+			noCredentials: true,
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
 			//set up
-			if err := os.Setenv(tt.envVar, tt.envVal); err != nil {
-				t.Errorf("KubeConfig.AddProfileTo() error = %v", err)
-				return
-			}
+			fs := memFiles(t, !tt.noCredentials)
 			k := &KubeConfig{
-				Contexts:       tt.fields.Contexts,
-				CurrentContext: tt.fields.CurrentContext,
-				Path:           tt.fields.Path,
-			}
-			if err := os.Setenv("KUBECONFIG", k.Path); err != nil {
-				t.Errorf("KubeConfig.AddProfileTo() error = %v", err)
-				return
+				Contexts:        tt.fields.Contexts,
+				CurrentContext:  tt.fields.CurrentContext,
+				Path:            memKubeConfigPath,
+				CredentialsPath: memCredentialsPath,
+				FS:              fs,
 			}
 			if err := k.AddProfileTo(tt.args.contextName, tt.args.profileName); (err != nil) != tt.wantErr {
 				t.Errorf("KubeConfig.AddProfileTo() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			return
-
-			r, err := GetConfigFile()
+			if tt.wantErr {
+				return
+			}
+			r, err := GetConfigFile(WithPath(memKubeConfigPath), WithFileSystem(fs))
 			if err != nil {
 				t.Errorf("KubeConfig.AddProfileTo() error read result config file = %v", err)
 				return
 			}
-			fmt.Printf("r = %+v\n", r)
 			ctx, _, err := r.GetContextBy(tt.args.contextName)
 			if err != nil {
 				t.Errorf("KubeConfig.AddProfileTo() error read result context = %v", err)
 				return
 			}
-			fmt.Printf("ctx = %+v\n", ctx)
 			if ctx.AWSprofile != tt.args.profileName {
 				t.Errorf("KubeConfig.AddProfileTo() got = %v, want = %v", ctx.AWSprofile, tt.args.profileName)
-				return
-			}
-			// teardown
-			if err = ioutil.WriteFile("testdata/.kube/config", testFileContent, 0644); err != nil {
-				t.Errorf("KubeConfig.AddProfileTo() error reset config file = %v", err)
-				return
 			}
 		})
 	}
@@ -649,7 +641,6 @@ func TestKubeConfig_AddNamespaceTo(t *testing.T) {
 		Preferences    interface{}
 		Contexts       []KubeContext
 		CurrentContext string
-		Path           string
 	}
 	type args struct {
 		contextName string
@@ -665,7 +656,6 @@ func TestKubeConfig_AddNamespaceTo(t *testing.T) {
 		{
 			name: "0positive - add a namespace to the context",
 			fields: fields{
-				Path:           "testdata/.kube/config", // fix contexts
 				CurrentContext: "cntxB",
 				Contexts: []KubeContext{
 					KubeContext{Name: "cntxA", AWSprofile: ""},
@@ -680,20 +670,24 @@ func TestKubeConfig_AddNamespaceTo(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := memFiles(t, false)
 			k := &KubeConfig{
 				ApiVersion:     tt.fields.ApiVersion,
 				Kind:           tt.fields.Kind,
 				Preferences:    tt.fields.Preferences,
 				Contexts:       tt.fields.Contexts,
 				CurrentContext: tt.fields.CurrentContext,
-				Path:           tt.fields.Path,
+				Path:           memKubeConfigPath,
+				FS:             fs,
 			}
 			if err := k.AddNamespaceTo(tt.args.contextName, tt.args.namespace); (err != nil) != tt.wantErr {
 				t.Errorf("KubeConfig.AddNamespaceTo() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			file, err := GetConfigFile()
+			file, err := GetConfigFile(WithPath(memKubeConfigPath), WithFileSystem(fs))
 			if err != nil {
 				t.Errorf("KubeConfig.AddNamespaceTo() error = %v", err)
 				return
@@ -715,7 +709,6 @@ func TestKubeConfig_AddNamespaceTo(t *testing.T) {
 func TestKubeConfig_UnSetDefault(t *testing.T) {
 	type fields struct {
 		CurrentContext string
-		Path           string
 	}
 	tests := []struct {
 		name               string
@@ -727,26 +720,26 @@ func TestKubeConfig_UnSetDefault(t *testing.T) {
 			name: "0positive - remove current-context",
 			fields: fields{
 				CurrentContext: "cntxB",
-				Path:           testFilePath,
 			},
 			wantErr:            false,
 			wantCurrentContext: "",
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			if err := os.Setenv("KUBECONFIG", tt.fields.Path); err != nil {
-				t.Errorf("UnSetDefault() error = %v", err)
-				return
-			}
+			t.Parallel()
+			fs := memFiles(t, false)
 			k := &KubeConfig{
 				CurrentContext: tt.fields.CurrentContext,
-				Path:           tt.fields.Path,
+				Path:           memKubeConfigPath,
+				StateDir:       memStateDir,
+				FS:             fs,
 			}
 			if err := k.UnSetDefault(); (err != nil) != tt.wantErr {
 				t.Errorf("KubeConfig.UnSetDefault() error = %v, wantErr %v", err, tt.wantErr)
 			}
-			r, err := GetConfigFile()
+			r, err := GetConfigFile(WithPath(memKubeConfigPath), WithFileSystem(fs))
 			if err != nil {
 				t.Errorf("KubeConfig.UnSetDefault() error read result config file = %v", err)
 				return
@@ -1,6 +1,8 @@
 module github.com/peterbueschel/eksdefault
 
 require (
+	github.com/go-ini/ini v1.42.0
+	github.com/gotk3/gotk3 v0.0.0-20190227183746-f63906bf28cd
 	github.com/peterbueschel/awsdefault v0.2.1
 	github.com/smartystreets/goconvey v0.0.0-20190222223459-a17d461953aa // indirect
 	github.com/urfave/cli v1.20.0
@@ -0,0 +1,118 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const historyFile = "history"
+
+// Callers recorded in the history.
+const (
+	CallerCLI  = "cli"
+	CallerGTK  = "gtk"
+	CallerExec = "exec"
+)
+
+// HistoryEntry is a single switch of the current-context. An empty To means the
+// current-context was unset.
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Profile string    `json:"profile"`
+	Caller  string    `json:"caller"`
+}
+
+// ReadHistory returns all recorded switches; the oldest first. The options are the same as
+// for GetConfigFile.
+func ReadHistory(opts ...Option) ([]HistoryEntry, error) {
+	return newKubeConfig(opts).readHistory()
+}
+
+func (k *KubeConfig) readHistory() ([]HistoryEntry, error) {
+	entries := []HistoryEntry{}
+	f, err := k.fs().ReadFile(k.statePath(historyFile))
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(f))
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) < 1 {
+			continue
+		}
+		e := HistoryEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("[HISTORY] malformed entry in line %d: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// LastUsed returns the time of the latest recorded switch to each context.
+func (k *KubeConfig) LastUsed() (map[string]time.Time, error) {
+	entries, err := k.readHistory()
+	if err != nil {
+		return nil, err
+	}
+	used := map[string]time.Time{}
+	for _, e := range entries {
+		if len(e.To) > 0 && e.Time.After(used[e.To]) {
+			used[e.To] = e.Time
+		}
+	}
+	return used, nil
+}
+
+// appendHistory adds the entry as JSON line to the history file.
+func (k *KubeConfig) appendHistory(e HistoryEntry) error {
+	path := k.statePath(historyFile)
+	if err := k.fs().MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	line, err := json.Marshal(&e)
+	if err != nil {
+		return err
+	}
+	return k.fs().AppendFile(path, append(line, '\n'), 0600)
+}
+
+// SetContextBack switches to the context, which was left by the n-th last switch. That means
+// SetContextBack(1) behaves like SetPreviousContext, but uses the aws-profile of the context.
+func (k *KubeConfig) SetContextBack(n int) error {
+	entries, err := k.readHistory()
+	if err != nil {
+		return err
+	}
+	if n < 1 || n > len(entries) {
+		return fmt.Errorf("[HISTORY] entry %d does not exist; the history contains %d entries", n, len(entries))
+	}
+	e := entries[len(entries)-n]
+	if len(e.From) < 1 {
+		return fmt.Errorf("[HISTORY] no current-context was set before the switch at %s", e.Time.Local().Format(time.RFC3339))
+	}
+	return k.SetContextTo(e.From)
+}
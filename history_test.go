@@ -0,0 +1,133 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadHistory(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	os.Remove(filepath.Join(StateDir(), historyFile))
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatalf("GetConfigFile() error = %v", err)
+	}
+	k.Caller = CallerGTK
+	if err := k.SetContextTo("cntxA"); err != nil {
+		t.Fatalf("KubeConfig.SetContextTo() error = %v", err)
+	}
+	if err := k.SetContextTo("cntxC"); err != nil {
+		t.Fatalf("KubeConfig.SetContextTo() error = %v", err)
+	}
+	if err := k.UnSetDefault(); err != nil {
+		t.Fatalf("KubeConfig.UnSetDefault() error = %v", err)
+	}
+	want := []HistoryEntry{
+		{From: "cntxB", To: "cntxA", Profile: "live", Caller: CallerGTK},
+		{From: "cntxA", To: "cntxC", Profile: "dev", Caller: CallerGTK},
+		{From: "cntxC", To: "", Profile: "", Caller: CallerGTK},
+	}
+	got, err := ReadHistory()
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadHistory() = %+v, want %+v", got, want)
+	}
+	for idx, e := range got {
+		if e.Time.IsZero() {
+			t.Errorf("ReadHistory() entry %d has no time", idx)
+		}
+		e.Time = want[idx].Time
+		if e != want[idx] {
+			t.Errorf("ReadHistory() entry %d = %+v, want %+v", idx, e, want[idx])
+		}
+	}
+}
+
+func TestKubeConfig_SetContextBack(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	os.Remove(filepath.Join(StateDir(), historyFile))
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatalf("GetConfigFile() error = %v", err)
+	}
+	for _, name := range []string{"cntxA", "cntxC"} {
+		if err := k.SetContextTo(name); err != nil {
+			t.Fatalf("KubeConfig.SetContextTo() error = %v", err)
+		}
+	}
+	if err := k.UnSetDefault(); err != nil {
+		t.Fatalf("KubeConfig.UnSetDefault() error = %v", err)
+	}
+	// history: cntxB -> cntxA, cntxA -> cntxC, cntxC -> ""
+	tests := []struct {
+		name    string
+		n       int
+		wantErr bool
+		wantCtx string
+	}{
+		{
+			name:    "0negative - zero is not an entry",
+			n:       0,
+			wantErr: true,
+			wantCtx: "",
+		},
+		{
+			name:    "1negative - not so many entries",
+			n:       4,
+			wantErr: true,
+			wantCtx: "",
+		},
+		{
+			name:    "2positive - back to the context before the unset",
+			n:       1,
+			wantCtx: "cntxC",
+		},
+		{
+			name:    "3negative - no current-context before the last switch",
+			n:       1,
+			wantErr: true,
+			wantCtx: "cntxC",
+		},
+		{
+			name:    "4positive - back to the first context",
+			n:       4,
+			wantCtx: "cntxB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := k.SetContextBack(tt.n); (err != nil) != tt.wantErr {
+				t.Errorf("KubeConfig.SetContextBack() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			r, err := GetConfigFile()
+			if err != nil {
+				t.Fatalf("GetConfigFile() error = %v", err)
+			}
+			if r.CurrentContext != tt.wantCtx {
+				t.Errorf("KubeConfig.SetContextBack() got = %v, want = %v", r.CurrentContext, tt.wantCtx)
+			}
+		})
+	}
+}
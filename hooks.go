@@ -0,0 +1,182 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// Events passed to the hooks.
+const (
+	HookSwitch  = "switch"
+	HookUnset   = "unset"
+	HookProfile = "profile"
+)
+
+// Phases of a hook; a failing pre hook aborts the change.
+const (
+	HookPre  = "pre"
+	HookPost = "post"
+)
+
+type (
+	// HookEvent describes the change, the hooks run for. The values are passed to the hooks
+	// as environment variables like EKSDEFAULT_OLD_CONTEXT or EKSDEFAULT_NEW_PROFILE.
+	HookEvent struct {
+		Event        string
+		OldContext   string
+		NewContext   string
+		OldProfile   string
+		NewProfile   string
+		OldCluster   string
+		NewCluster   string
+		OldNamespace string
+		NewNamespace string
+		Caller       string
+	}
+
+	// HookRunner runs the hooks of the given phase for the event.
+	HookRunner interface {
+		Run(phase string, e HookEvent) error
+	}
+
+	// DirHooks runs the executables named '<phase>-<event>', like 'pre-switch', and all
+	// executables inside '<phase>-<event>.d' of the directory in lexical order.
+	DirHooks struct {
+		Dir string
+		// Stdout and Stderr receive the output of the hooks; os.Stderr if not set, because
+		// the output of eksdefault itself may be evaluated by a shell.
+		Stdout io.Writer
+		Stderr io.Writer
+	}
+)
+
+// Environ returns the event as environment variables for the given phase.
+func (e HookEvent) Environ(phase string) []string {
+	return []string{
+		"EKSDEFAULT_HOOK=" + phase + "-" + e.Event,
+		"EKSDEFAULT_OLD_CONTEXT=" + e.OldContext,
+		"EKSDEFAULT_NEW_CONTEXT=" + e.NewContext,
+		"EKSDEFAULT_OLD_PROFILE=" + e.OldProfile,
+		"EKSDEFAULT_NEW_PROFILE=" + e.NewProfile,
+		"EKSDEFAULT_OLD_CLUSTER=" + e.OldCluster,
+		"EKSDEFAULT_NEW_CLUSTER=" + e.NewCluster,
+		"EKSDEFAULT_OLD_NAMESPACE=" + e.OldNamespace,
+		"EKSDEFAULT_NEW_NAMESPACE=" + e.NewNamespace,
+		"EKSDEFAULT_CALLER=" + e.Caller,
+	}
+}
+
+// executable reports whether the file can be run as hook.
+func executable(info os.FileInfo) bool {
+	return info.Mode().IsRegular() && (runtime.GOOS == "windows" || info.Mode()&0111 != 0)
+}
+
+// hooks returns the paths of the executables for the phase and event.
+func (h *DirHooks) hooks(name string) ([]string, error) {
+	paths := []string{}
+	path := filepath.Join(h.Dir, name)
+	if info, err := os.Stat(path); err == nil && executable(info) {
+		paths = append(paths, path)
+	}
+	infos, err := ioutil.ReadDir(path + ".d")
+	if os.IsNotExist(err) {
+		return paths, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	for _, info := range infos {
+		if executable(info) {
+			paths = append(paths, filepath.Join(path+".d", info.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// Run runs the hooks one after another and stops at the first failing hook.
+func (h *DirHooks) Run(phase string, e HookEvent) error {
+	paths, err := h.hooks(phase + "-" + e.Event)
+	if err != nil {
+		return err
+	}
+	stdout, stderr := h.Stdout, h.Stderr
+	if stdout == nil {
+		stdout = os.Stderr
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	for _, path := range paths {
+		cmd := exec.Command(path)
+		cmd.Env = append(os.Environ(), e.Environ(phase)...)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// HooksDir returns the directory of the hooks; either given by EKSDEFAULT_HOOKS_DIR or the
+// 'hooks' directory inside the config directory.
+func HooksDir() string {
+	if p := os.Getenv("EKSDEFAULT_HOOKS_DIR"); len(p) > 0 {
+		return p
+	}
+	return filepath.Join(ConfigDir(), "hooks")
+}
+
+// hook runs the hooks of the phase via the configured runner. Errors of pre hooks abort the
+// change. In DryRun mode no hooks are run.
+func (k *KubeConfig) hook(phase string, e HookEvent) error {
+	if k.DryRun {
+		return nil
+	}
+	runner := k.Hooks
+	if runner == nil {
+		dir := k.setting("hooks-dir")
+		if len(dir) < 1 {
+			dir = HooksDir()
+		}
+		runner = &DirHooks{Dir: dir}
+	}
+	e.Caller = k.Caller
+	if err := runner.Run(phase, e); err != nil {
+		if phase == HookPre {
+			return fmt.Errorf("[HOOK] the %s was aborted by the %s-%s hook: %v", e.Event, phase, e.Event, err)
+		}
+		return fmt.Errorf("[HOOK] the %s-%s hook failed: %v", phase, e.Event, err)
+	}
+	return nil
+}
+
+// contextInfo returns the cluster and namespace of the context or empty strings, if there is
+// no such context.
+func (k *KubeConfig) contextInfo(name string) (cluster, namespace string) {
+	ctx, _, err := k.GetContextBy(name)
+	if err != nil {
+		return "", ""
+	}
+	return ctx.Context.Cluster, ctx.Context.Namespace
+}
@@ -0,0 +1,172 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type hookFunc func(phase string, e HookEvent) error
+
+func (f hookFunc) Run(phase string, e HookEvent) error {
+	return f(phase, e)
+}
+
+func TestKubeConfig_hooks(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Caller = CallerCLI
+	events := []string{}
+	hooks := hookFunc(func(phase string, e HookEvent) error {
+		events = append(events, strings.Join([]string{phase, e.Event, e.OldContext, e.NewContext, e.OldProfile, e.NewProfile, e.OldNamespace, e.NewNamespace, e.Caller}, " "))
+		if phase == HookPre && (e.NewContext == "cntxA" || e.NewProfile == "anotherprofile") {
+			return errors.New("change freeze")
+		}
+		return nil
+	})
+	k.Hooks = hooks
+	if err := k.SetContextTo("cntxA"); err == nil || !strings.Contains(err.Error(), "change freeze") {
+		t.Errorf("SetContextTo() error = %v, want the switch aborted by the pre hook", err)
+	}
+	if reloaded, _ := GetConfigFile(); reloaded.CurrentContext != "cntxB" {
+		t.Errorf("SetContextTo() aborted, but current-context = %s", reloaded.CurrentContext)
+	}
+	if err := k.SetContextTo("cntxC"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddProfileTo("cntxC", "live"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.UnSetDefault(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, _, err := k.GetContextBy("cntxC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx.AWSprofile = "anotherprofile"
+	if err := k.UpdateContext("cntxC", *ctx); err == nil || !strings.Contains(err.Error(), "change freeze") {
+		t.Errorf("UpdateContext() error = %v, want the profile change aborted by the pre hook", err)
+	}
+	ctx.AWSprofile = "dev"
+	if err := k.UpdateContext("cntxC", *ctx); err != nil {
+		t.Fatal(err)
+	}
+	imported := filepath.Join(filepath.Dir(k.Path), "import")
+	if err := ioutil.WriteFile(imported, []byte(importKubeConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Merge(imported, MergeOptions{Profile: "anotherprofile"}); err == nil || !strings.Contains(err.Error(), "change freeze") {
+		t.Errorf("Merge() error = %v, want the profile change aborted by the pre hook", err)
+	}
+	if _, _, err := k.GetContextBy("kind-kind"); err == nil {
+		t.Errorf("Merge() aborted, but the contexts were added to the kube config")
+	}
+	if k, err = GetConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := k.GetContextBy("kind-kind"); err == nil {
+		t.Errorf("Merge() aborted, but the contexts were imported")
+	}
+	k.Caller, k.Hooks = CallerCLI, hooks
+	if _, err := k.Merge(imported, MergeOptions{Profile: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"pre switch cntxB cntxA  live bbbbb aaaaa cli",
+		"pre switch cntxB cntxC  dev bbbbb ccccc cli",
+		"post switch cntxB cntxC  dev bbbbb ccccc cli",
+		"pre profile cntxC cntxC dev live ccccc ccccc cli",
+		"post profile cntxC cntxC dev live ccccc ccccc cli",
+		"pre unset cntxC  live  ccccc  cli",
+		"post unset cntxC  live  ccccc  cli",
+		"pre profile cntxC cntxC live anotherprofile ccccc ccccc cli",
+		"pre profile cntxC cntxC live dev ccccc ccccc cli",
+		"post profile cntxC cntxC live dev ccccc ccccc cli",
+		"pre profile kind-kind kind-kind  anotherprofile   cli",
+		"pre profile kind-kind kind-kind  dev   cli",
+		"pre profile prod prod  dev   cli",
+		"post profile kind-kind kind-kind  dev   cli",
+		"post profile prod prod  dev   cli",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hooks got:\n%s\nwant:\n%s", strings.Join(events, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDirHooks_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "eksdefault-hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := filepath.Join(dir, "log")
+	script := "#!/bin/sh\necho \"$0 $EKSDEFAULT_HOOK $EKSDEFAULT_NEW_CONTEXT\" >> " + log + "\n"
+	files := map[string]os.FileMode{
+		"pre-switch":           0755,
+		"pre-switch.d/20-tmux": 0755,
+		"pre-switch.d/10-sso":  0755,
+		"pre-switch.d/README":  0644,
+		"post-unset":           0644,
+	}
+	for name, mode := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(script), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &DirHooks{Dir: dir}
+	if err := h.Run(HookPre, HookEvent{Event: HookSwitch, NewContext: "prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Run(HookPost, HookEvent{Event: HookUnset}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "pre-switch") + " pre-switch prod",
+		filepath.Join(dir, "pre-switch.d", "10-sso") + " pre-switch prod",
+		filepath.Join(dir, "pre-switch.d", "20-tmux") + " pre-switch prod",
+	}
+	if strings.TrimSpace(string(got)) != strings.Join(want, "\n") {
+		t.Errorf("Run() ran:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "pre-profile"), []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Run(HookPre, HookEvent{Event: HookProfile}); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Run() error = %v, want the exit status of the hook", err)
+	}
+}
@@ -0,0 +1,234 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	labelKey   = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]*[a-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+	labelValue = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+	setBased   = regexp.MustCompile(`^(\S+)\s+(in|notin)\s+\((.*)\)$`)
+)
+
+// Operators of a label selector requirement.
+const (
+	selectEquals    = "="
+	selectNotEquals = "!="
+	selectIn        = "in"
+	selectNotIn     = "notin"
+	selectExists    = "exists"
+	selectNotExists = "!"
+)
+
+type (
+	// requirement is a single term of a label selector.
+	requirement struct {
+		key      string
+		operator string
+		values   []string
+	}
+
+	// Selector is a parsed label selector like 'env=prod,team in (a,b),!legacy'. All
+	// requirements must match; the empty selector matches every context.
+	Selector []requirement
+)
+
+func checkLabel(key, value string) error {
+	if !labelKey.MatchString(key) {
+		return fmt.Errorf("[LABEL] invalid label key '%s'", key)
+	}
+	if !labelValue.MatchString(value) {
+		return fmt.Errorf("[LABEL] invalid value '%s' of the label '%s'", value, key)
+	}
+	return nil
+}
+
+// splitTerms splits the selector at the commas outside of parentheses.
+func splitTerms(s string) []string {
+	terms, depth, start := []string{}, 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, s[start:])
+}
+
+// ParseSelector parses a Kubernetes label selector supporting '=', '==', '!=', 'in',
+// 'notin', '<key>' and '!<key>'.
+func ParseSelector(s string) (Selector, error) {
+	sel := Selector{}
+	if len(strings.TrimSpace(s)) < 1 {
+		return sel, nil
+	}
+	for _, term := range splitTerms(s) {
+		term = strings.TrimSpace(term)
+		r := requirement{}
+		switch {
+		case setBased.MatchString(term):
+			m := setBased.FindStringSubmatch(term)
+			r.key, r.operator = m[1], m[2]
+			for _, v := range strings.Split(m[3], ",") {
+				r.values = append(r.values, strings.TrimSpace(v))
+			}
+		case strings.HasPrefix(term, "!"):
+			r.key, r.operator = strings.TrimSpace(term[1:]), selectNotExists
+		case strings.Contains(term, "!="):
+			kv := strings.SplitN(term, "!=", 2)
+			r.key, r.operator, r.values = strings.TrimSpace(kv[0]), selectNotEquals, []string{strings.TrimSpace(kv[1])}
+		case strings.Contains(term, "="):
+			kv := strings.SplitN(strings.Replace(term, "==", "=", 1), "=", 2)
+			r.key, r.operator, r.values = strings.TrimSpace(kv[0]), selectEquals, []string{strings.TrimSpace(kv[1])}
+		default:
+			r.key, r.operator = term, selectExists
+		}
+		if len(r.values) < 1 {
+			r.values = []string{""}
+		}
+		for _, v := range r.values {
+			if err := checkLabel(r.key, v); err != nil {
+				return nil, fmt.Errorf("[SELECTOR] invalid requirement '%s': %v", term, err)
+			}
+		}
+		sel = append(sel, r)
+	}
+	return sel, nil
+}
+
+// Matches reports whether the labels fulfill all requirements of the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, found := labels[r.key]
+		switch r.operator {
+		case selectEquals, selectIn:
+			if !found || !inList(value, r.values) {
+				return false
+			}
+		case selectNotEquals, selectNotIn:
+			if found && inList(value, r.values) {
+				return false
+			}
+		case selectExists:
+			if !found {
+				return false
+			}
+		case selectNotExists:
+			if found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FormatLabels returns the labels as sorted 'key=value' pairs separated by commas.
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ParseLabelChanges parses arguments like 'env=prod' to set and 'env-' to remove a label.
+func ParseLabelChanges(args []string) (map[string]string, []string, error) {
+	set, remove := map[string]string{}, []string{}
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "-") && !strings.Contains(arg, "=") {
+			key := strings.TrimSuffix(arg, "-")
+			if err := checkLabel(key, ""); err != nil {
+				return nil, nil, err
+			}
+			remove = append(remove, key)
+			continue
+		}
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("[LABEL] '%s' is neither 'key=value' nor 'key-'", arg)
+		}
+		if err := checkLabel(kv[0], kv[1]); err != nil {
+			return nil, nil, err
+		}
+		set[kv[0]] = kv[1]
+	}
+	return set, remove, nil
+}
+
+// SelectContexts returns the names of all contexts whose labels match the selector.
+func (k *KubeConfig) SelectContexts(selector string) ([]string, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, c := range k.Contexts {
+		if sel.Matches(c.Labels) {
+			names = append(names, c.Name)
+		}
+	}
+	return names, nil
+}
+
+// SetLabels adds or changes the labels of the context and removes the labels listed in
+// remove.
+func (k *KubeConfig) SetLabels(contextName string, set map[string]string, remove []string) error {
+	ctx, idx, err := k.GetContextBy(contextName)
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{}
+	for key, value := range ctx.Labels {
+		labels[key] = value
+	}
+	for key, value := range set {
+		if err := checkLabel(key, value); err != nil {
+			return err
+		}
+		labels[key] = value
+	}
+	for _, key := range remove {
+		delete(labels, key)
+	}
+	old := FormatLabels(ctx.Labels)
+	if len(labels) < 1 {
+		labels = nil
+	}
+	k.Contexts[idx].Labels = labels
+	if err := k.SaveContexts(); err != nil {
+		return err
+	}
+	return k.audit(AuditRecord{
+		Action:  ActionLabel,
+		Context: contextName,
+		Old:     old,
+		New:     FormatLabels(labels),
+		Profile: ctx.AWSprofile,
+		Account: k.AccountOf(ctx),
+	})
+}
@@ -0,0 +1,127 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "payments", "region": "eu-west-1"}
+	tests := []struct {
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		{selector: "", want: true},
+		{selector: "env=prod", want: true},
+		{selector: "env==prod,team=payments", want: true},
+		{selector: "env=dev", want: false},
+		{selector: "env!=dev", want: true},
+		{selector: "owner!=me", want: true},
+		{selector: "env in (dev, prod)", want: true},
+		{selector: "env notin (dev,prod)", want: false},
+		{selector: "region,!legacy", want: true},
+		{selector: "legacy", want: false},
+		{selector: "!env", want: false},
+		{selector: "env in (dev,prod),team notin (core)", want: true},
+		{selector: "example.com/owner=x", want: false},
+		{selector: "env=pr od", wantErr: true},
+		{selector: "=prod", wantErr: true},
+		{selector: "env in (a b)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := ParseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && sel.Matches(labels) != tt.want {
+				t.Errorf("Matches() = %v, want %v", !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLabelChanges(t *testing.T) {
+	set, remove, err := ParseLabelChanges([]string{"env=prod", "team-", "empty="})
+	if err != nil || FormatLabels(set) != "empty=,env=prod" || strings.Join(remove, ",") != "team" {
+		t.Errorf("ParseLabelChanges() = %v, %v, %v", set, remove, err)
+	}
+	for _, arg := range []string{"env", "-", "env=a=b", "bad key=x"} {
+		if _, _, err := ParseLabelChanges([]string{arg}); err == nil {
+			t.Errorf("ParseLabelChanges(%s), want error", arg)
+		}
+	}
+}
+
+func TestKubeConfig_SetLabels(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, labels := range map[string]map[string]string{
+		"cntxA": {"env": "prod", "team": "payments"},
+		"cntxB": {"env": "prod", "team": "core"},
+		"cntxC": {"env": "dev"},
+	} {
+		if err := k.SetLabels(name, labels, nil); err != nil {
+			t.Fatalf("SetLabels() error = %v", err)
+		}
+	}
+	if err := k.SetLabels("cntxA", map[string]string{"env": "in valid"}, nil); err == nil {
+		t.Errorf("SetLabels() with an invalid value, want error")
+	}
+	if err := k.SetLabels("cntxB", map[string]string{"tier": "1"}, []string{"team"}); err != nil {
+		t.Fatalf("SetLabels() error = %v", err)
+	}
+
+	k, err = GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx, _, _ := k.GetContextBy("cntxB"); FormatLabels(ctx.Labels) != "env=prod,tier=1" {
+		t.Errorf("labels of cntxB = %v, want env=prod,tier=1", ctx.Labels)
+	}
+	for selector, want := range map[string]string{
+		"env=prod":         "cntxA,cntxB",
+		"team":             "cntxA",
+		"env notin (prod)": "cntxC,minikube",
+		"!env":             "minikube",
+	} {
+		if got, err := k.SelectContexts(selector); err != nil || strings.Join(got, ",") != want {
+			t.Errorf("SelectContexts(%s) = %v, %v, want %s", selector, got, err, want)
+		}
+	}
+	contexts, err := k.Query(ContextQuery{Selector: "env=prod", Profile: "live", SortBy: SortByName})
+	if err != nil || len(contexts) != 2 {
+		t.Errorf("Query() = %v, %v, want cntxA and cntxB", contexts, err)
+	}
+	if _, err := k.Query(ContextQuery{Selector: "env=="}); err != nil {
+		t.Errorf("Query() with empty value error = %v", err)
+	}
+	if _, err := k.Query(ContextQuery{Selector: "!"}); err == nil {
+		t.Errorf("Query() with invalid selector, want error")
+	}
+	if err := k.SetLabels("cntxC", nil, []string{"env"}); err != nil {
+		t.Fatal(err)
+	}
+	if ctx, _, _ := k.GetContextBy("cntxC"); ctx.Labels != nil {
+		t.Errorf("labels of cntxC = %v, want none", ctx.Labels)
+	}
+}
@@ -0,0 +1,125 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+type (
+	// MemFileSystem keeps the files in memory. It lets tools and tests work on kube configs
+	// and AWS credentials files without touching the disk or the environment. It is safe for
+	// concurrent use.
+	MemFileSystem struct {
+		mu    sync.RWMutex
+		files map[string]memFile
+	}
+
+	memFile struct {
+		data    []byte
+		perm    os.FileMode
+		modTime time.Time
+	}
+
+	memFileInfo struct {
+		name string
+		file memFile
+	}
+)
+
+// NewMemFileSystem returns a MemFileSystem containing the given files.
+func NewMemFileSystem(files map[string][]byte) *MemFileSystem {
+	m := &MemFileSystem{files: map[string]memFile{}}
+	for p, data := range files {
+		m.files[p] = memFile{data: append([]byte{}, data...), perm: 0644, modTime: time.Now()}
+	}
+	return m
+}
+
+// ReadFile returns a copy of the content of the file.
+func (m *MemFileSystem) ReadFile(p string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.files[p]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: p, Err: os.ErrNotExist}
+	}
+	return append([]byte{}, f.data...), nil
+}
+
+// WriteFile stores a copy of the data as content of the file.
+func (m *MemFileSystem) WriteFile(p string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = map[string]memFile{}
+	}
+	m.files[p] = memFile{data: append([]byte{}, data...), perm: perm, modTime: time.Now()}
+	return nil
+}
+
+// AppendFile appends a copy of the data to the content of the file.
+func (m *MemFileSystem) AppendFile(p string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = map[string]memFile{}
+	}
+	f, ok := m.files[p]
+	if !ok {
+		f.perm = perm
+	}
+	f.data = append(append([]byte{}, f.data...), data...)
+	f.modTime = time.Now()
+	m.files[p] = f
+	return nil
+}
+
+// Remove deletes the file.
+func (m *MemFileSystem) Remove(p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[p]; !ok {
+		return &os.PathError{Op: "remove", Path: p, Err: os.ErrNotExist}
+	}
+	delete(m.files, p)
+	return nil
+}
+
+// Stat returns the size, permissions and modification time of the file.
+func (m *MemFileSystem) Stat(p string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.files[p]
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	return memFileInfo{name: path.Base(p), file: f}, nil
+}
+
+// MkdirAll does nothing; the directories of the files exist implicitly.
+func (m *MemFileSystem) MkdirAll(p string, perm os.FileMode) error {
+	return nil
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return int64(len(fi.file.data)) }
+func (fi memFileInfo) Mode() os.FileMode  { return fi.file.perm }
+func (fi memFileInfo) ModTime() time.Time { return fi.file.modTime }
+func (fi memFileInfo) IsDir() bool        { return false }
+func (fi memFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,247 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Strategies to resolve name conflicts while merging another kube config.
+const (
+	MergeSkip      = "skip"
+	MergeOverwrite = "overwrite"
+	MergeRename    = "rename"
+)
+
+type (
+	// MergeOptions configure Merge.
+	MergeOptions struct {
+		// Strategy is one of the Merge* constants; default is MergeSkip.
+		Strategy string
+		// Suffix is appended to the names of conflicting entries with MergeRename. Default
+		// is '-merged'.
+		Suffix string
+		// Profile is set as aws-profile of all imported contexts, if not empty.
+		Profile string
+	}
+
+	// MergeEntry reports what happened to a single context, cluster or user while merging.
+	MergeEntry struct {
+		Kind    string
+		Name    string
+		Action  string
+		NewName string
+	}
+)
+
+// Actions reported in MergeEntry.
+const (
+	MergeAdded     = "added"
+	MergeUnchanged = "unchanged"
+	MergeSkipped   = "skipped"
+	MergeReplaced  = "overwritten"
+	MergeRenamed   = "renamed"
+)
+
+// Merge imports the contexts, clusters and users of the kube config at path. Entries equal
+// to existing ones are ignored; other name conflicts are resolved by the strategy. Contexts
+// using a skipped cluster or user are skipped too, renamed clusters and users are also
+// renamed inside the imported contexts. The kube config stays unchanged, if a pre hook
+// aborts the merge.
+func (k *KubeConfig) Merge(path string, opts MergeOptions) ([]MergeEntry, error) {
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = MergeSkip
+	case MergeSkip, MergeOverwrite, MergeRename:
+	default:
+		return nil, fmt.Errorf("[MERGE] unknown strategy '%s'; use %s, %s or %s", opts.Strategy, MergeSkip, MergeOverwrite, MergeRename)
+	}
+	if len(opts.Suffix) < 1 {
+		opts.Suffix = "-merged"
+	}
+	if len(opts.Profile) > 0 {
+		awsfile, err := k.credentialsFile()
+		if err != nil {
+			return nil, err
+		}
+		if !inList(opts.Profile, awsfile.GetProfilesNames()) {
+			return nil, fmt.Errorf("[MERGE] the profile '%s' does not exist in '%s'", opts.Profile, awsfile.Path)
+		}
+	}
+	other := &KubeConfig{Path: path, FS: k.FS}
+	if err := other.read(); err != nil {
+		return nil, err
+	}
+	// merged collects the result, so that k stays unchanged until the pre hooks passed
+	merged := &KubeConfig{
+		Clusters: append([]KubeCluster{}, k.Clusters...),
+		Users:    append([]KubeUser{}, k.Users...),
+		Contexts: append([]KubeContext{}, k.Contexts...),
+	}
+	entries := []MergeEntry{}
+	// resolve returns the name the imported entry gets and the action; an empty name
+	// means the entry is not imported.
+	resolve := func(name string, exists, equal func(string) bool) (string, string) {
+		if !exists(name) {
+			return name, MergeAdded
+		}
+		if equal(name) {
+			return "", MergeUnchanged
+		}
+		switch opts.Strategy {
+		case MergeOverwrite:
+			return name, MergeReplaced
+		case MergeRename:
+			newName := name + opts.Suffix
+			for i := 2; exists(newName); i++ {
+				newName = fmt.Sprintf("%s%s%d", name, opts.Suffix, i)
+			}
+			return newName, MergeRenamed
+		}
+		return "", MergeSkipped
+	}
+	report := func(kind, name, newName, action string) {
+		e := MergeEntry{Kind: kind, Name: name, Action: action}
+		if action == MergeRenamed {
+			e.NewName = newName
+		}
+		entries = append(entries, e)
+	}
+
+	// the new names of the imported clusters and users; an empty name keeps the existing entry
+	clusterNames, skippedClusters := map[string]string{}, map[string]bool{}
+	for _, c := range other.Clusters {
+		newName, action := resolve(c.Name,
+			func(n string) bool { _, _, err := merged.GetClusterBy(n); return err == nil },
+			func(n string) bool {
+				own, _, _ := merged.GetClusterBy(n)
+				return reflect.DeepEqual(own.Cluster, c.Cluster)
+			},
+		)
+		report("cluster", c.Name, newName, action)
+		clusterNames[c.Name] = newName
+		if len(newName) < 1 {
+			skippedClusters[c.Name] = action == MergeSkipped
+			continue
+		}
+		c.Name = newName
+		if _, idx, err := merged.GetClusterBy(newName); err == nil {
+			merged.Clusters[idx] = c
+		} else {
+			merged.Clusters = append(merged.Clusters, c)
+		}
+	}
+	userNames, skippedUsers := map[string]string{}, map[string]bool{}
+	for _, u := range other.Users {
+		newName, action := resolve(u.Name,
+			func(n string) bool { _, _, err := merged.GetUserBy(n); return err == nil },
+			func(n string) bool { own, _, _ := merged.GetUserBy(n); return reflect.DeepEqual(own.User, u.User) },
+		)
+		report("user", u.Name, newName, action)
+		userNames[u.Name] = newName
+		if len(newName) < 1 {
+			skippedUsers[u.Name] = action == MergeSkipped
+			continue
+		}
+		u.Name = newName
+		if _, idx, err := merged.GetUserBy(newName); err == nil {
+			merged.Users[idx] = u
+		} else {
+			merged.Users = append(merged.Users, u)
+		}
+	}
+	events := []HookEvent{}
+	for _, c := range other.Contexts {
+		if c.Context == nil {
+			c.Context = &Context{}
+		}
+		if skippedClusters[c.Context.Cluster] || skippedUsers[c.Context.User] {
+			// the context would use the existing cluster or user of the same name
+			report("context", c.Name, "", MergeSkipped)
+			continue
+		}
+		oldProfile := c.AWSprofile
+		settings := *c.Context
+		if n := clusterNames[settings.Cluster]; len(n) > 0 {
+			settings.Cluster = n
+		}
+		if n := userNames[settings.User]; len(n) > 0 {
+			settings.User = n
+		}
+		c.Context = &settings
+		if len(opts.Profile) > 0 {
+			c.AWSprofile = opts.Profile
+		}
+		newName, action := resolve(c.Name,
+			func(n string) bool { _, _, err := merged.GetContextBy(n); return err == nil },
+			func(n string) bool {
+				own, _, _ := merged.GetContextBy(n)
+				return own.AWSprofile == c.AWSprofile && reflect.DeepEqual(*own.Context, *c.Context)
+			},
+		)
+		report("context", c.Name, newName, action)
+		if len(newName) < 1 {
+			continue
+		}
+		c.Name = newName
+		if own, _, err := merged.GetContextBy(newName); err == nil {
+			oldProfile = own.AWSprofile
+		}
+		if len(opts.Profile) > 0 && oldProfile != opts.Profile {
+			events = append(events, HookEvent{
+				Event:        HookProfile,
+				OldContext:   c.Name,
+				NewContext:   c.Name,
+				OldProfile:   oldProfile,
+				NewProfile:   opts.Profile,
+				OldCluster:   c.Context.Cluster,
+				NewCluster:   c.Context.Cluster,
+				OldNamespace: c.Context.Namespace,
+				NewNamespace: c.Context.Namespace,
+			})
+		}
+		if _, idx, err := merged.GetContextBy(newName); err == nil {
+			c.Protected = c.Protected || merged.Contexts[idx].Protected
+			if len(c.Labels) < 1 {
+				c.Labels = merged.Contexts[idx].Labels
+			}
+			merged.Contexts[idx] = c
+		} else {
+			merged.Contexts = append(merged.Contexts, c)
+		}
+	}
+	if err := merged.checkDuplicates(); err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if err := k.hook(HookPre, e); err != nil {
+			return nil, err
+		}
+	}
+	k.Clusters, k.Users, k.Contexts = merged.Clusters, merged.Users, merged.Contexts
+	if err := k.SaveContexts(); err != nil {
+		return nil, err
+	}
+	if err := k.audit(AuditRecord{Action: ActionMerge, New: path, Profile: opts.Profile}); err != nil {
+		return entries, err
+	}
+	for _, e := range events {
+		if err := k.hook(HookPost, e); err != nil {
+			return entries, err
+		}
+	}
+	return entries, nil
+}
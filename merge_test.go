@@ -0,0 +1,185 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const importKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://127.0.0.1:6443
+  name: kind
+- cluster:
+    server: https://other
+  name: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+contexts:
+- context:
+    cluster: kind
+    user: kind
+  name: kind-kind
+- context:
+    cluster: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+    user: arn:aws:eks:eu-west-1:123456789012:cluster/prod
+  name: prod
+users:
+- name: kind
+  user:
+    token: other
+`
+
+func TestKubeConfig_Merge(t *testing.T) {
+	prod := "arn:aws:eks:eu-west-1:123456789012:cluster/prod"
+	tests := []struct {
+		name         string
+		content      string
+		opts         MergeOptions
+		wantErr      bool
+		want         []MergeEntry
+		wantContexts map[string]KubeContext
+		wantServer   string
+	}{
+		{
+			name: "0positive - skip",
+			opts: MergeOptions{},
+			want: []MergeEntry{
+				{Kind: "cluster", Name: "kind", Action: MergeUnchanged},
+				{Kind: "cluster", Name: prod, Action: MergeSkipped},
+				{Kind: "user", Name: "kind", Action: MergeSkipped},
+				{Kind: "context", Name: "kind-kind", Action: MergeSkipped},
+				{Kind: "context", Name: "prod", Action: MergeSkipped},
+			},
+			wantContexts: map[string]KubeContext{
+				"prod": {Name: "prod", AWSprofile: "live", Context: &Context{Cluster: prod, User: prod}},
+			},
+			wantServer: "https://ABC.gr7.eu-west-1.eks.amazonaws.com",
+		},
+		{
+			name: "1positive - overwrite with profile",
+			opts: MergeOptions{Strategy: MergeOverwrite, Profile: "dev"},
+			want: []MergeEntry{
+				{Kind: "cluster", Name: "kind", Action: MergeUnchanged},
+				{Kind: "cluster", Name: prod, Action: MergeReplaced},
+				{Kind: "user", Name: "kind", Action: MergeReplaced},
+				{Kind: "context", Name: "kind-kind", Action: MergeAdded},
+				{Kind: "context", Name: "prod", Action: MergeReplaced},
+			},
+			wantContexts: map[string]KubeContext{
+				"kind-kind": {Name: "kind-kind", AWSprofile: "dev", Context: &Context{Cluster: "kind", User: "kind"}},
+				"prod":      {Name: "prod", AWSprofile: "dev", Context: &Context{Cluster: prod, User: prod}},
+			},
+			wantServer: "https://other",
+		},
+		{
+			name: "2positive - rename",
+			opts: MergeOptions{Strategy: MergeRename, Suffix: "-b"},
+			want: []MergeEntry{
+				{Kind: "cluster", Name: "kind", Action: MergeUnchanged},
+				{Kind: "cluster", Name: prod, Action: MergeRenamed, NewName: prod + "-b"},
+				{Kind: "user", Name: "kind", Action: MergeRenamed, NewName: "kind-b"},
+				{Kind: "context", Name: "kind-kind", Action: MergeAdded},
+				{Kind: "context", Name: "prod", Action: MergeRenamed, NewName: "prod-b"},
+			},
+			wantContexts: map[string]KubeContext{
+				"kind-kind": {Name: "kind-kind", Context: &Context{Cluster: "kind", User: "kind-b"}},
+				"prod":      {Name: "prod", AWSprofile: "live", Context: &Context{Cluster: prod, User: prod}},
+				"prod-b":    {Name: "prod-b", Context: &Context{Cluster: prod + "-b", User: prod}},
+			},
+			wantServer: "https://ABC.gr7.eu-west-1.eks.amazonaws.com",
+		},
+		{
+			name:    "3negative - duplicated context names",
+			content: "contexts:\n- name: a\n- name: a\n",
+			wantErr: true,
+		},
+		{
+			name:    "4negative - unknown strategy",
+			opts:    MergeOptions{Strategy: "merge"},
+			wantErr: true,
+		},
+		{
+			name:    "5negative - unknown profile",
+			opts:    MergeOptions{Profile: "unknown"},
+			wantErr: true,
+		},
+		{
+			name: "6positive - skip the contexts of skipped clusters",
+			content: "clusters:\n- cluster: {server: https://other}\n  name: " + prod + "\n" +
+				"contexts:\n- context: {cluster: " + prod + ", user: " + prod + "}\n  name: staging\n",
+			want: []MergeEntry{
+				{Kind: "cluster", Name: prod, Action: MergeSkipped},
+				{Kind: "context", Name: "staging", Action: MergeSkipped},
+			},
+			wantContexts: map[string]KubeContext{
+				"prod": {Name: "prod", AWSprofile: "live", Context: &Context{Cluster: prod, User: prod}},
+			},
+			wantServer: "https://ABC.gr7.eu-west-1.eks.amazonaws.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, teardown := setupEKSConfig(t)
+			defer teardown()
+			content := tt.content
+			if len(content) < 1 {
+				content = importKubeConfig
+			}
+			other := filepath.Join(filepath.Dir(path), "other")
+			if err := ioutil.WriteFile(other, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			k, err := GetConfigFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := k.Merge(other, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Merge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+			k, err = GetConfigFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(k.Contexts) != len(tt.wantContexts) {
+				t.Errorf("Merge() resulted in %d contexts, want %d", len(k.Contexts), len(tt.wantContexts))
+			}
+			for name, want := range tt.wantContexts {
+				ctx, _, err := k.GetContextBy(name)
+				if err != nil {
+					t.Errorf("context %s is missing", name)
+					continue
+				}
+				if ctx.AWSprofile != want.AWSprofile || *ctx.Context != *want.Context {
+					t.Errorf("context %s = %s %+v, want %s %+v", name, ctx.AWSprofile, ctx.Context, want.AWSprofile, want.Context)
+				}
+			}
+			if c, _, _ := k.GetClusterBy(prod); c.Cluster.Server != tt.wantServer {
+				t.Errorf("server of %s = %s, want %s", prod, c.Cluster.Server, tt.wantServer)
+			}
+		})
+	}
+}
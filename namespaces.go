@@ -0,0 +1,127 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+const namespaceCacheFile = "namespaces.cache"
+
+// NamespaceCacheTTL is the time, the namespaces listed by CachedNamespaces are reused.
+var NamespaceCacheTTL = 2 * time.Minute
+
+type (
+	// cachedNamespaces are the namespaces of the cluster used by a context.
+	cachedNamespaces struct {
+		Server string    `json:"server"`
+		Time   time.Time `json:"time"`
+		Names  []string  `json:"names"`
+	}
+	// namespaceCache maps the context names to their namespaces.
+	namespaceCache map[string]cachedNamespaces
+)
+
+func (k *KubeConfig) readNamespaceCache() namespaceCache {
+	cache := namespaceCache{}
+	if data, err := k.fs().ReadFile(k.statePath(namespaceCacheFile)); err == nil {
+		_ = json.Unmarshal(data, &cache) // an invalid cache is rebuilt
+	}
+	return cache
+}
+
+// ListNamespaces asks the API server of the context for all namespaces. The result is
+// cached for CachedNamespaces.
+func (k *KubeConfig) ListNamespaces(contextName string, timeout time.Duration) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client, err := k.newAPIClient(ctx, contextName)
+	if err != nil {
+		return nil, err
+	}
+	status, body, err := client.do(ctx, http.MethodGet, "/api/v1/namespaces", "")
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("[NAMESPACE] unable to list the namespaces: %s", http.StatusText(status))
+	}
+	list := struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("[NAMESPACE] invalid namespace list: %v", err)
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, i := range list.Items {
+		names = append(names, i.Metadata.Name)
+	}
+	sort.Strings(names)
+	if !k.DryRun {
+		cache := k.readNamespaceCache()
+		cache[contextName] = cachedNamespaces{Server: client.server, Time: time.Now().UTC(), Names: names}
+		if data, err := json.Marshal(cache); err == nil {
+			_ = k.writeState(namespaceCacheFile, data) // the cache is optional
+		}
+	}
+	return names, nil
+}
+
+// CachedNamespaces returns the namespaces listed within the last NamespaceCacheTTL or asks
+// the API server via ListNamespaces.
+func (k *KubeConfig) CachedNamespaces(contextName string, timeout time.Duration) ([]string, error) {
+	ctx, _, err := k.GetContextBy(contextName)
+	if err != nil {
+		return nil, err
+	}
+	cluster, _, _ := k.GetClusterBy(ctx.Context.Cluster)
+	cached, ok := k.readNamespaceCache()[contextName]
+	if ok && cached.Server == cluster.Cluster.Server && time.Since(cached.Time) < NamespaceCacheTTL {
+		return cached.Names, nil
+	}
+	return k.ListNamespaces(contextName, timeout)
+}
+
+// CheckNamespace returns an error, if the namespace does not exist in the cluster of the
+// context.
+func (k *KubeConfig) CheckNamespace(contextName, namespace string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client, err := k.newAPIClient(ctx, contextName)
+	if err != nil {
+		return err
+	}
+	status, _, err := client.do(ctx, http.MethodGet, "/api/v1/namespaces/"+url.PathEscape(namespace), "")
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("[NAMESPACE] the namespace '%s' does not exist in the cluster of the context '%s'", namespace, contextName)
+	}
+	return fmt.Errorf("[NAMESPACE] unable to check the namespace '%s': %s", namespace, http.StatusText(status))
+}
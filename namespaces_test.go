@@ -0,0 +1,86 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKubeConfig_namespaces(t *testing.T) {
+	path, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	server := newAPIServer(true)
+	defer server.Close()
+	config := fmt.Sprintf(`clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: %s
+  name: c
+contexts:
+- context: {cluster: c, user: good}
+  name: ok
+- context: {cluster: c, user: bad}
+  name: bad
+users:
+- name: good
+  user: {token: good}
+- name: bad
+  user: {token: bad}
+`, serverCA(server), server.URL)
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.CheckNamespace("ok", "payments", time.Second); err != nil {
+		t.Errorf("CheckNamespace() error = %v", err)
+	}
+	if err := k.CheckNamespace("ok", "paymnets", time.Second); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("CheckNamespace() of a missing namespace error = %v, want it to not exist", err)
+	}
+	if err := k.CheckNamespace("bad", "payments", time.Second); err == nil {
+		t.Errorf("CheckNamespace() with invalid credentials, want error")
+	}
+	if _, err := k.ListNamespaces("bad", time.Second); err == nil {
+		t.Errorf("ListNamespaces() with invalid credentials, want error")
+	}
+
+	want := "default,kube-system,payments"
+	got, err := k.CachedNamespaces("ok", time.Second)
+	if err != nil || strings.Join(got, ",") != want {
+		t.Errorf("CachedNamespaces() = %v, %v, want %s", got, err, want)
+	}
+	// the cache is used, even if the server is gone
+	server.Close()
+	if got, err = k.CachedNamespaces("ok", time.Second); err != nil || strings.Join(got, ",") != want {
+		t.Errorf("CachedNamespaces() = %v, %v, want the cached %s", got, err, want)
+	}
+	if _, err = k.ListNamespaces("ok", time.Second); err == nil {
+		t.Errorf("ListNamespaces() of a stopped server, want error")
+	}
+	old := NamespaceCacheTTL
+	defer func() { NamespaceCacheTTL = old }()
+	NamespaceCacheTTL = 0
+	if _, err = k.CachedNamespaces("ok", time.Second); err == nil {
+		t.Errorf("CachedNamespaces() with an expired cache of a stopped server, want error")
+	}
+}
@@ -0,0 +1,95 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"errors"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+const namespaceHistoryFile = "namespaces.history"
+
+// MaxRecentNamespaces is the number of previous namespaces remembered per context.
+var MaxRecentNamespaces = 10
+
+var (
+	NoPreviousNamespace = errors.New("no previous namespace recorded yet for this context")
+)
+
+func (k *KubeConfig) readNamespaceHistory() (map[string][]string, error) {
+	history := map[string][]string{}
+	f, err := k.fs().ReadFile(k.statePath(namespaceHistoryFile))
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return history, err
+	}
+	return history, yaml.Unmarshal(f, &history)
+}
+
+// rememberNamespace puts the namespace in front of the recent namespaces of the context.
+func (k *KubeConfig) rememberNamespace(contextName, namespace string) error {
+	history, err := k.readNamespaceHistory()
+	if err != nil {
+		return err
+	}
+	recent := []string{namespace}
+	for _, n := range history[contextName] {
+		if n != namespace && len(recent) < MaxRecentNamespaces {
+			recent = append(recent, n)
+		}
+	}
+	history[contextName] = recent
+	f, err := yaml.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return k.writeState(namespaceHistoryFile, f)
+}
+
+// RecentNamespaces returns the namespaces used by the context before, the most recent
+// first. The current namespace of the context is not included.
+func (k *KubeConfig) RecentNamespaces(contextName string) ([]string, error) {
+	ctx, _, err := k.GetContextBy(contextName)
+	if err != nil {
+		return nil, err
+	}
+	history, err := k.readNamespaceHistory()
+	if err != nil {
+		return nil, err
+	}
+	recent := []string{}
+	for _, n := range history[contextName] {
+		if n != ctx.Context.Namespace {
+			recent = append(recent, n)
+		}
+	}
+	return recent, nil
+}
+
+// SetPreviousNamespace switches the context back to the namespace used before.
+func (k *KubeConfig) SetPreviousNamespace(contextName string) error {
+	recent, err := k.RecentNamespaces(contextName)
+	if err != nil {
+		return err
+	}
+	if len(recent) < 1 {
+		return NoPreviousNamespace
+	}
+	return k.AddNamespaceTo(contextName, recent[0])
+}
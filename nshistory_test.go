@@ -0,0 +1,74 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKubeConfig_SetPreviousNamespace(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	os.Remove(filepath.Join(StateDir(), namespaceHistoryFile))
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetPreviousNamespace("minikube"); err != NoPreviousNamespace {
+		t.Errorf("SetPreviousNamespace() error = %v, want %v", err, NoPreviousNamespace)
+	}
+	for _, ns := range []string{"one", "two", "three", "one"} {
+		if err := k.AddNamespaceTo("cntxA", ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recent, err := k.RecentNamespaces("cntxA")
+	if err != nil || strings.Join(recent, ",") != "three,two,aaaaa" {
+		t.Errorf("RecentNamespaces() = %v, %v, want three,two,aaaaa", recent, err)
+	}
+	// the history is kept per context
+	if recent, err = k.RecentNamespaces("cntxC"); err != nil || len(recent) > 0 {
+		t.Errorf("RecentNamespaces() = %v, %v, want none", recent, err)
+	}
+	if err := k.SetPreviousNamespace("cntxA"); err != nil {
+		t.Fatalf("SetPreviousNamespace() error = %v", err)
+	}
+	if ctx, _, _ := k.GetContextBy("cntxA"); ctx.Context.Namespace != "three" {
+		t.Errorf("SetPreviousNamespace() namespace = %s, want three", ctx.Context.Namespace)
+	}
+	// toggles between the last two namespaces
+	if err := k.SetPreviousNamespace("cntxA"); err != nil {
+		t.Fatalf("SetPreviousNamespace() error = %v", err)
+	}
+	if ctx, _, _ := k.GetContextBy("cntxA"); ctx.Context.Namespace != "one" {
+		t.Errorf("SetPreviousNamespace() namespace = %s, want one", ctx.Context.Namespace)
+	}
+
+	old := MaxRecentNamespaces
+	defer func() { MaxRecentNamespaces = old }()
+	MaxRecentNamespaces = 2
+	if err := k.AddNamespaceTo("cntxA", "four"); err != nil {
+		t.Fatal(err)
+	}
+	if recent, err = k.RecentNamespaces("cntxA"); err != nil || strings.Join(recent, ",") != "one,three" {
+		t.Errorf("RecentNamespaces() = %v, %v, want one,three", recent, err)
+	}
+	if _, err := k.RecentNamespaces("unknown"); err == nil {
+		t.Errorf("RecentNamespaces() of an unknown context, want error")
+	}
+}
@@ -0,0 +1,197 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-ini/ini"
+	"github.com/peterbueschel/awsdefault"
+)
+
+type (
+	// FileSystem reads and writes the kube config, the AWS credentials file, the config file,
+	// the files referenced by the kube config and the state of eksdefault.
+	FileSystem interface {
+		ReadFile(path string) ([]byte, error)
+		WriteFile(path string, data []byte, perm os.FileMode) error
+		AppendFile(path string, data []byte, perm os.FileMode) error
+		Remove(path string) error
+		Stat(path string) (os.FileInfo, error)
+		MkdirAll(path string, perm os.FileMode) error
+	}
+
+	// OSFileSystem is the FileSystem of the operating system.
+	OSFileSystem struct{}
+
+	// Option changes where the kube config and the related files are read from and written to.
+	// Without options the environment variables like KUBECONFIG are used.
+	Option func(k *KubeConfig)
+)
+
+// ReadFile reads the file.
+func (OSFileSystem) ReadFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+// WriteFile writes the file.
+func (OSFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(path, data, perm)
+}
+
+// AppendFile appends the data to the file and creates it, if it does not exist yet.
+func (OSFileSystem) AppendFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Remove deletes the file.
+func (OSFileSystem) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// Stat returns the file info.
+func (OSFileSystem) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// MkdirAll creates the directory and its parents.
+func (OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// WithPath reads the kube config from the path instead of KUBECONFIG.
+func WithPath(path string) Option {
+	return func(k *KubeConfig) {
+		k.Path = path
+	}
+}
+
+// WithCredentialsPath reads the AWS credentials file from the path instead of
+// AWS_SHARED_CREDENTIALS_FILE.
+func WithCredentialsPath(path string) Option {
+	return func(k *KubeConfig) {
+		k.CredentialsPath = path
+	}
+}
+
+// WithConfigPath reads the settings of eksdefault from the path instead of EKSDEFAULT_CONFIG.
+func WithConfigPath(path string) Option {
+	return func(k *KubeConfig) {
+		k.ConfigPath = path
+	}
+}
+
+// WithStateDir keeps the state like the history inside the directory instead of StateDir.
+func WithStateDir(dir string) Option {
+	return func(k *KubeConfig) {
+		k.StateDir = dir
+	}
+}
+
+// WithFileSystem reads and writes the files via the given FileSystem.
+func WithFileSystem(fs FileSystem) Option {
+	return func(k *KubeConfig) {
+		k.FS = fs
+	}
+}
+
+// newKubeConfig returns an empty kube config with the options applied.
+func newKubeConfig(opts []Option) *KubeConfig {
+	k := &KubeConfig{}
+	for _, opt := range opts {
+		opt(k)
+	}
+	if len(k.Path) < 1 {
+		k.Path = KubeConfigPath()
+	}
+	return k
+}
+
+func (k *KubeConfig) fs() FileSystem {
+	if k.FS == nil {
+		return OSFileSystem{}
+	}
+	return k.FS
+}
+
+func (k *KubeConfig) credentialsPath() string {
+	if len(k.CredentialsPath) < 1 {
+		return CredentialsPath()
+	}
+	return k.CredentialsPath
+}
+
+// statePath returns the path of the state file with the given name.
+func (k *KubeConfig) statePath(name string) string {
+	dir := k.StateDir
+	if len(dir) < 1 {
+		dir = StateDir()
+	}
+	return filepath.Join(dir, name)
+}
+
+// writeState writes the state file with the given name; only readable by the owner.
+func (k *KubeConfig) writeState(name string, data []byte) error {
+	path := k.statePath(name)
+	if err := k.fs().MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return k.fs().WriteFile(path, data, 0600)
+}
+
+func (k *KubeConfig) configPath() string {
+	if len(k.ConfigPath) < 1 {
+		return ConfigPath()
+	}
+	return k.ConfigPath
+}
+
+// credentialsFile reads the AWS credentials file like awsdefault.GetCredentialsFile, but
+// from the configured path and FileSystem.
+func (k *KubeConfig) credentialsFile() (*awsdefault.CredentialsFile, error) {
+	path := k.credentialsPath()
+	data, err := k.fs().ReadFile(path)
+	if err != nil {
+		return &awsdefault.CredentialsFile{Path: path}, err
+	}
+	f, err := ini.InsensitiveLoad(data)
+	return &awsdefault.CredentialsFile{Content: f, Path: path}, err
+}
+
+// setting returns the value of the key of the config file. Its environment variable
+// listed in ConfigEnv overrules the config file.
+func (k *KubeConfig) setting(key string) string {
+	if value, ok := os.LookupEnv(ConfigEnv[key]); ok {
+		return value
+	}
+	if k.settings == nil {
+		c, err := k.readConfig()
+		if err != nil {
+			c = &Config{}
+		}
+		k.settings = c
+	}
+	return k.settings.Get(key)
+}
@@ -0,0 +1,65 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetConfigFile_options(t *testing.T) {
+	creds, err := ioutil.ReadFile("testdata/.aws/credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := NewMemFileSystem(map[string][]byte{
+				"/kube":   testFileContent,
+				"/creds":  creds,
+				"/config": []byte("fallback-profile: dev\n"),
+			})
+			opts := []Option{WithPath("/kube"), WithCredentialsPath("/creds"), WithConfigPath("/config"), WithFileSystem(fs)}
+			k, err := GetConfigFile(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(k.Contexts) != 4 || k.CurrentContext != "cntxB" {
+				t.Fatalf("GetConfigFile() = %+v, want the contexts of /kube", k)
+			}
+			// minikube has no aws-profile; the fallback profile comes from /config
+			if err := k.SetContextTo("minikube"); err != nil {
+				t.Fatal(err)
+			}
+			if kube, _ := fs.ReadFile("/kube"); !strings.Contains(string(kube), "current-context: minikube") {
+				t.Errorf("SetContextTo() did not write to the FileSystem:\n%s", kube)
+			}
+			if c, _ := fs.ReadFile("/creds"); string(c) == string(creds) {
+				t.Errorf("SetContextTo() did not change the default profile in /creds")
+			}
+			names, err := GetProfileNames(opts...)
+			if err != nil || strings.Join(names, ",") != "anotherprofile,dev,live" {
+				t.Errorf("GetProfileNames() = %v, %v", names, err)
+			}
+			if _, err := GetConfigFile(WithPath("/missing"), WithFileSystem(fs)); !os.IsNotExist(err) {
+				t.Errorf("GetConfigFile() of a missing file error = %v, want not exist", err)
+			}
+		})
+	}
+}
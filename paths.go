@@ -0,0 +1,80 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+func home() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("USERPROFILE")
+	}
+	return os.Getenv("HOME")
+}
+
+// KubeConfigPath returns the path of the kube config either inside the HOME directory or
+// given by the environment variable KUBECONFIG.
+func KubeConfigPath() string {
+	if p := os.Getenv("KUBECONFIG"); len(p) > 0 {
+		return p
+	}
+	return filepath.Join(home(), ".kube", "config")
+}
+
+// CredentialsPath returns the path of the AWS credentials file the same way awsdefault does;
+// either inside the HOME directory or given by AWS_SHARED_CREDENTIALS_FILE.
+func CredentialsPath() string {
+	if p := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); len(p) > 0 {
+		return p
+	}
+	return filepath.Join(home(), ".aws", "credentials")
+}
+
+// ConfigDir returns the directory of the eksdefault configuration like the hooks. It can be
+// changed via EKSDEFAULT_CONFIG_DIR and follows XDG_CONFIG_HOME otherwise.
+func ConfigDir() string {
+	if p := os.Getenv("EKSDEFAULT_CONFIG_DIR"); len(p) > 0 {
+		return p
+	}
+	if p := os.Getenv("XDG_CONFIG_HOME"); len(p) > 0 {
+		return filepath.Join(p, "eksdefault")
+	}
+	if runtime.GOOS == "windows" {
+		if p := os.Getenv("APPDATA"); len(p) > 0 {
+			return filepath.Join(p, "eksdefault")
+		}
+	}
+	return filepath.Join(home(), ".config", "eksdefault")
+}
+
+// StateDir returns the directory, where eksdefault keeps its own state like caches. It can be
+// changed via EKSDEFAULT_STATE_DIR and follows XDG_STATE_HOME otherwise.
+func StateDir() string {
+	if p := os.Getenv("EKSDEFAULT_STATE_DIR"); len(p) > 0 {
+		return p
+	}
+	if p := os.Getenv("XDG_STATE_HOME"); len(p) > 0 {
+		return filepath.Join(p, "eksdefault")
+	}
+	if runtime.GOOS == "windows" {
+		if p := os.Getenv("LOCALAPPDATA"); len(p) > 0 {
+			return filepath.Join(p, "eksdefault")
+		}
+	}
+	return filepath.Join(home(), ".local", "state", "eksdefault")
+}
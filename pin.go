@@ -0,0 +1,150 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// PinFileName is the name of the file pinning a directory tree to a context.
+	PinFileName = ".eksdefault"
+	// PinEnv is set to the path of the pin file while a pin is active in a shell.
+	PinEnv = "EKSDEFAULT_PIN"
+	// UnpinnedKubeConfigEnv keeps the path of the kube config the pinned context is taken
+	// from, while KUBECONFIG points to the pinned kube config.
+	UnpinnedKubeConfigEnv = "EKSDEFAULT_KUBECONFIG"
+	// UnpinnedProfileEnv keeps the AWS_PROFILE set before the pin became active.
+	UnpinnedProfileEnv = "EKSDEFAULT_AWS_PROFILE"
+
+	pinsDir = "pins"
+)
+
+// Pin is the content of a '.eksdefault' file. The file contains either only the name of the
+// context or the context and optionally a namespace as YAML:
+//
+//	context: prod
+//	namespace: payments
+type Pin struct {
+	Context   string `yaml:"context"`
+	Namespace string `yaml:"namespace,omitempty"`
+	// Path is the file, the pin was read from.
+	Path string `yaml:"-"`
+}
+
+// ReadPin reads the pin file at the given path.
+func ReadPin(path string) (*Pin, error) {
+	f, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pin{Path: path}
+	var name string
+	if yaml.Unmarshal(f, &name) == nil && len(name) > 0 {
+		p.Context = strings.TrimSpace(name)
+	} else if err := yaml.Unmarshal(f, p); err != nil {
+		return nil, fmt.Errorf("[PIN] unable to read %s: %v", path, err)
+	}
+	if len(p.Context) < 1 {
+		return nil, fmt.Errorf("[PIN] no context set in %s", path)
+	}
+	return p, nil
+}
+
+// FindPin looks for a pin file in the given directory and all of its parents. It returns nil
+// without an error, if none of the directories contains a pin file.
+func FindPin(dir string) (*Pin, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, PinFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return ReadPin(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// GetUnpinnedConfigFile reads the kube config the same way GetConfigFile does, but while a
+// pin is active, it reads the kube config the pinned context was taken from. A path given via
+// WithPath still wins.
+func GetUnpinnedConfigFile(opts ...Option) (*KubeConfig, error) {
+	if p := os.Getenv(UnpinnedKubeConfigEnv); len(p) > 0 {
+		opts = append([]Option{WithPath(p)}, opts...)
+	}
+	return GetConfigFile(opts...)
+}
+
+// Pinned returns a self-contained kube config like Extract with the pinned context as
+// current-context and the namespace of the pin, if any.
+func (k *KubeConfig) Pinned(p *Pin) (*KubeConfig, error) {
+	if _, _, err := k.GetContextBy(p.Context); err != nil {
+		return nil, fmt.Errorf("[PIN] the context %s pinned by %s does not exist", p.Context, p.Path)
+	}
+	pinned, err := k.Extract(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Namespace) > 0 {
+		pinned.Contexts[0].Context.Namespace = p.Namespace
+	}
+	return pinned, nil
+}
+
+// WritePinned writes the kube config returned by Pinned into the state directory and returns
+// its path. The file is only readable by the owner, because it contains credentials. Like a
+// switch, pinning a protected context must be confirmed via ConfirmProtected.
+func (k *KubeConfig) WritePinned(p *Pin) (string, error) {
+	if ctx, _, err := k.GetContextBy(p.Context); err == nil && k.IsProtected(ctx) && !k.DryRun {
+		if k.ConfirmProtected == nil {
+			return "", NotConfirmed
+		}
+		if err = k.ConfirmProtected(ctx); err != nil {
+			return "", err
+		}
+	}
+	pinned, err := k.Pinned(p)
+	if err != nil {
+		return "", err
+	}
+	content, err := pinned.Content()
+	if err != nil {
+		return "", err
+	}
+	name := strings.NewReplacer("/", "_", ":", "_", string(filepath.Separator), "_").Replace(p.Context)
+	if len(p.Namespace) > 0 {
+		name += "." + p.Namespace
+	}
+	path := k.statePath(filepath.Join(pinsDir, name+".config"))
+	if k.DryRun {
+		return path, nil
+	}
+	if err := k.fs().MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	return path, k.fs().WriteFile(path, content, 0600)
+}
@@ -0,0 +1,97 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindPin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eksdefault-pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nested := filepath.Join(dir, "service", "deploy")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := FindPin(nested); err != nil || p != nil {
+		t.Fatalf("FindPin() = %v, %v, want no pin", p, err)
+	}
+
+	tests := []struct {
+		content string
+		want    Pin
+		wantErr bool
+	}{
+		{content: "prod\n", want: Pin{Context: "prod"}},
+		{content: "context: prod\nnamespace: payments\n", want: Pin{Context: "prod", Namespace: "payments"}},
+		{content: "namespace: payments\n", wantErr: true},
+		{content: "context: [prod\n", wantErr: true},
+	}
+	path := filepath.Join(dir, "service", PinFileName)
+	for _, tt := range tests {
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := FindPin(nested)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FindPin() of %q error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		tt.want.Path = path
+		if *got != tt.want {
+			t.Errorf("FindPin() of %q = %+v, want %+v", tt.content, *got, tt.want)
+		}
+	}
+}
+
+func TestKubeConfig_WritePinned(t *testing.T) {
+	_, teardown := setupEKSConfig(t)
+	defer teardown()
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.WritePinned(&Pin{Context: "stage", Path: "/srv/.eksdefault"}); err == nil || !strings.Contains(err.Error(), "/srv/.eksdefault") {
+		t.Errorf("WritePinned() of an unknown context error = %v, want the pin file named", err)
+	}
+	path, err := k.WritePinned(&Pin{Context: "prod", Namespace: "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	pinned, err := GetConfigFile(WithPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinned.CurrentContext != "prod" || len(pinned.Contexts) != 1 || pinned.Contexts[0].Context.Namespace != "payments" {
+		t.Errorf("WritePinned() = %+v, want only context prod with namespace payments", pinned)
+	}
+
+	t.Setenv(UnpinnedKubeConfigEnv, k.Path)
+	t.Setenv("KUBECONFIG", path)
+	if unpinned, err := GetUnpinnedConfigFile(); err != nil || unpinned.Path != k.Path {
+		t.Errorf("GetUnpinnedConfigFile() = %v, %v, want %s", unpinned, err, k.Path)
+	}
+}
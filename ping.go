@@ -0,0 +1,155 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	selfSubjectReview = `{"apiVersion":"authentication.k8s.io/v1","kind":"SelfSubjectReview"}`
+	// selfSubjectAccessReview is sent to API servers older than 1.28 instead.
+	selfSubjectAccessReview = `{"apiVersion":"authorization.k8s.io/v1","kind":"SelfSubjectAccessReview",` +
+		`"spec":{"resourceAttributes":{"verb":"get","resource":"namespaces"}}}`
+)
+
+type (
+	// PingResult describes the health of the cluster used by a context.
+	PingResult struct {
+		Context string
+		Server  string
+		// Reachable is set, if the API server answered /version.
+		Reachable bool
+		// AuthOK is set, if the API server accepted the credentials of the user.
+		AuthOK bool
+		// User is the name the API server knows the user by; if reported.
+		User    string
+		Version string
+		// Latency is the round trip time of the /version request.
+		Latency time.Duration
+		Err     error
+	}
+)
+
+// version requests /version.
+func (p *apiClient) version(ctx context.Context, r *PingResult) error {
+	start := time.Now()
+	status, body, err := p.do(ctx, http.MethodGet, "/version", "")
+	if err != nil {
+		return err
+	}
+	r.Latency = time.Since(start)
+	r.Reachable = true
+	if status != http.StatusOK {
+		return nil // answered; the server does not allow /version for this user
+	}
+	v := struct {
+		GitVersion string `json:"gitVersion"`
+	}{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("[PING] invalid answer of /version: %v", err)
+	}
+	r.Version = v.GitVersion
+	return nil
+}
+
+// auth creates a SelfSubjectReview or, if the API server does not know it, a
+// SelfSubjectAccessReview. A forbidden request is also authenticated.
+func (p *apiClient) auth(ctx context.Context, r *PingResult) error {
+	status, body, err := p.do(ctx, http.MethodPost, "/apis/authentication.k8s.io/v1/selfsubjectreviews", selfSubjectReview)
+	if err == nil && status == http.StatusNotFound {
+		status, body, err = p.do(ctx, http.MethodPost, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", selfSubjectAccessReview)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == http.StatusUnauthorized:
+		return fmt.Errorf("[PING] credentials not accepted")
+	case status == http.StatusForbidden:
+		r.AuthOK = true
+	case status >= 200 && status < 300:
+		r.AuthOK = true
+		review := struct {
+			Status struct {
+				UserInfo struct {
+					Username string `json:"username"`
+				} `json:"userInfo"`
+			} `json:"status"`
+		}{}
+		if json.Unmarshal(body, &review) == nil {
+			r.User = review.Status.UserInfo.Username
+		}
+	default:
+		return fmt.Errorf("[PING] unexpected answer %d: %s", status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Ping checks, whether the API server of the context is reachable and accepts the
+// credentials of the user. Both requests are sent in parallel and must finish within the
+// timeout, which includes running the credential plugin.
+func (k *KubeConfig) Ping(contextName string, timeout time.Duration) PingResult {
+	r := PingResult{Context: contextName}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	p, err := k.newAPIClient(ctx, contextName)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Server = p.server
+	var (
+		wg              sync.WaitGroup
+		versionE, authE error
+	)
+	// both write to different fields of r
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		versionE = p.version(ctx, &r)
+	}()
+	go func() {
+		defer wg.Done()
+		authE = p.auth(ctx, &r)
+	}()
+	wg.Wait()
+	if r.Err = versionE; r.Err == nil {
+		r.Err = authE
+	}
+	return r
+}
+
+// PingAll runs Ping for all given contexts in parallel. The results have the same order
+// like the names.
+func (k *KubeConfig) PingAll(contextNames []string, timeout time.Duration) []PingResult {
+	results := make([]PingResult, len(contextNames))
+	var wg sync.WaitGroup
+	for idx, name := range contextNames {
+		wg.Add(1)
+		go func(idx int, name string) {
+			defer wg.Done()
+			results[idx] = k.Ping(name, timeout)
+		}(idx, name)
+	}
+	wg.Wait()
+	return results
+}
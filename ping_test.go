@@ -0,0 +1,173 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newAPIServer starts a TLS server answering /version, the namespaces default, kube-system
+// and payments and, if reviews is set, the SelfSubjectReview. Only the token 'good' is
+// accepted.
+func newAPIServer(reviews bool) *httptest.Server {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			fmt.Fprint(w, `{"major":"1","minor":"29","gitVersion":"v1.29.1-eks-1"}`)
+			return
+		}
+		if r.URL.Path == "/apis/authentication.k8s.io/v1/selfsubjectreviews" && !reviews {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/apis/authentication.k8s.io/v1/selfsubjectreviews":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"status":{"userInfo":{"username":"admin"}}}`)
+		case "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"status":{"allowed":true}}`)
+		case "/api/v1/namespaces":
+			fmt.Fprint(w, `{"items":[{"metadata":{"name":"payments"}},{"metadata":{"name":"default"}},{"metadata":{"name":"kube-system"}}]}`)
+		case "/api/v1/namespaces/default", "/api/v1/namespaces/kube-system", "/api/v1/namespaces/payments":
+			fmt.Fprint(w, `{"metadata":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	s.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // handshakes with untrusted clients
+	s.StartTLS()
+	return s
+}
+
+// serverCA returns the certificate of the TLS server as certificate-authority-data.
+func serverCA(s *httptest.Server) string {
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+}
+
+func TestKubeConfig_Ping(t *testing.T) {
+	path, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	current, old := newAPIServer(true), newAPIServer(false)
+	defer current.Close()
+	defer old.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	config := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: %s
+  name: current
+- cluster:
+    certificate-authority-data: %s
+    server: %s
+  name: old
+- cluster:
+    server: %s
+  name: insecure
+- cluster:
+    insecure-skip-tls-verify: true
+    server: https://%s
+  name: closed
+contexts:
+- context: {cluster: current, user: token}
+  name: ok
+- context: {cluster: current, user: plugin}
+  name: plugin
+  aws-profile: live
+- context: {cluster: current, user: bad}
+  name: bad
+- context: {cluster: old, user: token}
+  name: old
+- context: {cluster: insecure, user: token}
+  name: untrusted
+- context: {cluster: closed, user: token}
+  name: closed
+users:
+- name: token
+  user:
+    token: good
+- name: bad
+  user:
+    token: bad
+- name: plugin
+  user:
+    exec:
+      command: sh
+      args:
+      - -c
+      - 'test "$AWS_PROFILE" = live && echo "{\"status\":{\"token\":\"good\"}}"'
+`, serverCA(current), current.URL, serverCA(old), old.URL, current.URL, closed.Addr())
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		want    PingResult
+		wantErr string
+	}{
+		{name: "ok", want: PingResult{Reachable: true, AuthOK: true, User: "admin", Version: "v1.29.1-eks-1"}},
+		{name: "plugin", want: PingResult{Reachable: true, AuthOK: true, User: "admin", Version: "v1.29.1-eks-1"}},
+		{name: "bad", want: PingResult{Reachable: true, Version: "v1.29.1-eks-1"}, wantErr: "credentials not accepted"},
+		{name: "old", want: PingResult{Reachable: true, AuthOK: true, Version: "v1.29.1-eks-1"}},
+		{name: "untrusted", wantErr: "certificate"},
+		{name: "closed", wantErr: "connection refused"},
+	}
+	names := []string{}
+	for _, tt := range tests {
+		names = append(names, tt.name)
+	}
+	results := k.PingAll(names, 5*time.Second)
+	for i, tt := range tests {
+		got := results[i]
+		if got.Context != tt.name {
+			t.Errorf("PingAll()[%d] is %s, want %s", i, got.Context, tt.name)
+		}
+		if (got.Err == nil) != (len(tt.wantErr) < 1) || got.Err != nil && !strings.Contains(got.Err.Error(), tt.wantErr) {
+			t.Errorf("Ping(%s) error = %v, want %q", tt.name, got.Err, tt.wantErr)
+		}
+		if got.Reachable != tt.want.Reachable || got.AuthOK != tt.want.AuthOK || got.User != tt.want.User || got.Version != tt.want.Version {
+			t.Errorf("Ping(%s) = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got.Reachable && got.Latency <= 0 {
+			t.Errorf("Ping(%s) latency = %v, want it measured", tt.name, got.Latency)
+		}
+	}
+	if got := k.Ping("unknown", time.Second); got.Err == nil {
+		t.Errorf("Ping() of an unknown context, want error")
+	}
+}
@@ -0,0 +1,88 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"errors"
+	"os"
+
+	"github.com/peterbueschel/awsdefault"
+	"gopkg.in/yaml.v2"
+)
+
+const previousFile = "previous"
+
+var (
+	NoPreviousContext = errors.New("no previous context recorded yet")
+)
+
+// Previous stores the context and the default AWS profile, which were used before the last
+// successful SetContextTo.
+type Previous struct {
+	Context string `yaml:"context"`
+	Profile string `yaml:"profile"`
+}
+
+// ReadPrevious returns the context and AWS profile used before the last switch. The options
+// are the same as for GetConfigFile.
+func ReadPrevious(opts ...Option) (*Previous, error) {
+	return newKubeConfig(opts).readPrevious()
+}
+
+func (k *KubeConfig) readPrevious() (*Previous, error) {
+	p := &Previous{}
+	f, err := k.fs().ReadFile(k.statePath(previousFile))
+	if os.IsNotExist(err) {
+		return p, NoPreviousContext
+	}
+	if err != nil {
+		return p, err
+	}
+	if err = yaml.Unmarshal(f, p); err != nil {
+		return p, err
+	}
+	if len(p.Context) < 1 {
+		return p, NoPreviousContext
+	}
+	return p, nil
+}
+
+func (k *KubeConfig) writePrevious(p Previous) error {
+	f, err := yaml.Marshal(&p)
+	if err != nil {
+		return err
+	}
+	return k.writeState(previousFile, f)
+}
+
+// activeProfile returns the name of the profile currently used as default inside the
+// AWS credentials file or an empty string, if none is set.
+func activeProfile(awsfile *awsdefault.CredentialsFile) string {
+	n, idx, err := awsfile.GetUsedProfileNameAndIndex()
+	if err != nil || idx < 0 {
+		return ""
+	}
+	return n
+}
+
+// SetPreviousContext switches back to the context used before the last SetContextTo and
+// restores the default AWS profile of that time.
+func (k *KubeConfig) SetPreviousContext() error {
+	prev, err := k.readPrevious()
+	if err != nil {
+		return err
+	}
+	return k.switchContext(prev.Context, prev.Profile)
+}
@@ -0,0 +1,104 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/peterbueschel/awsdefault"
+)
+
+func TestKubeConfig_SetPreviousContext(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	os.Remove(filepath.Join(StateDir(), previousFile))
+
+	tests := []struct {
+		name        string
+		switchTo    string
+		setDefault  string
+		wantErr     bool
+		wantCtx     string
+		wantProfile string
+	}{
+		{
+			name:    "0negative - nothing recorded yet",
+			wantErr: true,
+			wantCtx: "cntxB",
+		},
+		{
+			name:        "1positive - back to the context before",
+			switchTo:    "cntxC",
+			wantCtx:     "cntxB",
+			wantProfile: "live",
+		},
+		{
+			name:        "2positive - toggle again",
+			wantCtx:     "cntxC",
+			wantProfile: "dev",
+		},
+		{
+			name:        "3positive - restore the aws default of that time",
+			setDefault:  "anotherprofile",
+			switchTo:    "cntxA",
+			wantCtx:     "cntxC",
+			wantProfile: "anotherprofile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := GetConfigFile()
+			if err != nil {
+				t.Fatalf("GetConfigFile() error = %v", err)
+			}
+			awsfile, err := awsdefault.GetCredentialsFile()
+			if err != nil {
+				t.Fatalf("GetCredentialsFile() error = %v", err)
+			}
+			if len(tt.setDefault) > 0 {
+				if err := awsfile.SetDefaultTo(tt.setDefault); err != nil {
+					t.Fatalf("SetDefaultTo() error = %v", err)
+				}
+			}
+			if len(tt.switchTo) > 0 {
+				if err := k.SetContextTo(tt.switchTo); err != nil {
+					t.Fatalf("KubeConfig.SetContextTo() error = %v", err)
+				}
+			}
+			if err := k.SetPreviousContext(); (err != nil) != tt.wantErr {
+				t.Errorf("KubeConfig.SetPreviousContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			r, err := GetConfigFile()
+			if err != nil {
+				t.Fatalf("GetConfigFile() error = %v", err)
+			}
+			if r.CurrentContext != tt.wantCtx {
+				t.Errorf("KubeConfig.SetPreviousContext() got = %v, want = %v", r.CurrentContext, tt.wantCtx)
+			}
+			if len(tt.wantProfile) > 0 {
+				awsfile, err := awsdefault.GetCredentialsFile()
+				if err != nil {
+					t.Fatalf("GetCredentialsFile() error = %v", err)
+				}
+				if n := activeProfile(awsfile); n != tt.wantProfile {
+					t.Errorf("KubeConfig.SetPreviousContext() wrong aws profile. Got = %v, want = %v", n, tt.wantProfile)
+				}
+			}
+		})
+	}
+}
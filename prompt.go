@@ -0,0 +1,202 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+const promptCacheFile = "prompt.cache"
+
+type (
+	// PromptInfo contains everything a shell prompt segment shows about the current-context.
+	PromptInfo struct {
+		Context   string `json:"context"`
+		Namespace string `json:"namespace"`
+		Cluster   string `json:"cluster"`
+		User      string `json:"user"`
+		// Profile is the aws-profile configured for the current-context.
+		Profile string `json:"profile"`
+		// ActiveProfile is the profile currently used as default inside the AWS credentials file.
+		ActiveProfile string `json:"active-profile"`
+	}
+
+	// fileStamp identifies a version of a file by its path, size and modification time.
+	fileStamp struct {
+		Path    string `json:"path"`
+		Size    int64  `json:"size"`
+		ModTime int64  `json:"mod-time"`
+	}
+
+	promptCache struct {
+		KubeConfig  fileStamp  `json:"kubeconfig"`
+		Credentials fileStamp  `json:"credentials"`
+		Info        PromptInfo `json:"info"`
+	}
+)
+
+func stampOf(fs FileSystem, path string) fileStamp {
+	s := fileStamp{Path: path}
+	if fi, err := fs.Stat(path); err == nil {
+		s.Size = fi.Size()
+		s.ModTime = fi.ModTime().UnixNano()
+	}
+	return s
+}
+
+// ReadPromptInfo returns the information about the current-context. The result is cached
+// inside the StateDir and only computed again, if the kube config or the AWS credentials
+// file changed. Instead of parsing the whole kube config, only the lines needed for the
+// current-context are scanned. The options are the same as for GetConfigFile.
+func ReadPromptInfo(opts ...Option) (*PromptInfo, error) {
+	k := newKubeConfig(opts)
+	kube, creds := stampOf(k.fs(), k.Path), stampOf(k.fs(), k.credentialsPath())
+	if data, err := k.fs().ReadFile(k.statePath(promptCacheFile)); err == nil {
+		cache := promptCache{}
+		if json.Unmarshal(data, &cache) == nil && cache.KubeConfig == kube && cache.Credentials == creds {
+			return &cache.Info, nil
+		}
+	}
+	data, err := k.fs().ReadFile(kube.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := scanCurrentContext(data)
+	if !ok {
+		// unusual layout; fall back to the full parse
+		file, err := GetConfigFile(opts...)
+		if err != nil {
+			return nil, err
+		}
+		info = &PromptInfo{Context: file.CurrentContext}
+		if ctx, _, err := file.GetContextBy(file.CurrentContext); err == nil {
+			info.Namespace = ctx.Context.Namespace
+			info.Cluster = ctx.Context.Cluster
+			info.User = ctx.Context.User
+			info.Profile = ctx.AWSprofile
+		}
+	}
+	if awsfile, err := k.credentialsFile(); err == nil {
+		if n, idx, err := awsfile.GetUsedProfileNameAndIndex(); err == nil && idx >= 0 {
+			info.ActiveProfile = n
+		}
+	}
+	cache, err := json.Marshal(promptCache{KubeConfig: kube, Credentials: creds, Info: *info})
+	if err == nil {
+		_ = k.writeState(promptCacheFile, cache) // the cache is optional
+	}
+	return info, nil
+}
+
+// scanCurrentContext extracts the current-context and its settings from the kube config line
+// by line. It reports false, if the layout is not the usual block style written by kubectl
+// or eksdefault.
+func scanCurrentContext(data []byte) (*PromptInfo, bool) {
+	var (
+		section, current string
+		listIndent       = -1
+		// itemIndent is the indent of the keys of a context, blockIndent the one of the keys
+		// inside its nested block, like 'context:' or 'labels:'
+		itemIndent, blockIndent int
+		block                   string
+		item                    map[string]string
+		items                   []map[string]string
+	)
+	unquote := func(s string) string {
+		s = strings.TrimSpace(s)
+		if len(s) > 1 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+			return s[1 : len(s)-1]
+		}
+		return s
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) { // JSON
+		return nil, false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if len(trimmed) < 1 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		if indent == 0 && !strings.HasPrefix(trimmed, "-") {
+			kv := strings.SplitN(trimmed, ":", 2)
+			section = kv[0]
+			if section == "current-context" && len(kv) > 1 {
+				current = unquote(kv[1])
+			}
+			continue
+		}
+		if section != "contexts" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if listIndent < 0 {
+				listIndent = indent
+			}
+			if indent == listIndent {
+				item = map[string]string{}
+				items = append(items, item)
+				key := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+				indent += len(trimmed) - len(key)
+				itemIndent, block, trimmed = indent, "", key
+			}
+		}
+		if item == nil {
+			return nil, false
+		}
+		kv := strings.SplitN(trimmed, ":", 2)
+		if len(kv) < 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), unquote(kv[1])
+		switch {
+		case indent == itemIndent:
+			block, blockIndent = "", -1
+			if len(value) < 1 {
+				block = key
+			} else {
+				item[key] = value
+			}
+		case block != "context" || len(value) < 1:
+			// other nested blocks like the labels are not needed
+		case blockIndent < 0 || indent == blockIndent:
+			blockIndent = indent
+			item[key] = value
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, false
+	}
+	info := &PromptInfo{Context: current}
+	if len(current) < 1 {
+		return info, true
+	}
+	for _, i := range items {
+		if i["name"] == current {
+			info.Namespace = i["namespace"]
+			info.Cluster = i["cluster"]
+			info.User = i["user"]
+			info.Profile = i["aws-profile"]
+			return info, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,160 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanCurrentContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   PromptInfo
+		wantOk bool
+	}{
+		{
+			name:   "0positive - testdata kube config",
+			data:   string(testFileContent),
+			want:   PromptInfo{Context: "cntxB", Namespace: "bbbbb", Cluster: "clstrB", User: "userB", Profile: "live"},
+			wantOk: true,
+		},
+		{
+			name:   "1positive - indented list and quoted values",
+			data:   "apiVersion: v1\ncontexts:\n  - name: \"prod/eu\"\n    aws-profile: 'live'\n    context:\n      cluster: c1\n      namespace: payments\ncurrent-context: prod/eu\n",
+			want:   PromptInfo{Context: "prod/eu", Namespace: "payments", Cluster: "c1", Profile: "live"},
+			wantOk: true,
+		},
+		{
+			name: "2positive - labels named like the settings of the context",
+			data: "contexts:\n- aws-profile: live\n  context:\n    cluster: c1\n    namespace: payments\n  labels:\n    aws-profile: x\n    name: y\n    cluster: z\n" +
+				"  name: prod\n- context:\n    cluster: c2\n  name: y\ncurrent-context: prod\n",
+			want:   PromptInfo{Context: "prod", Namespace: "payments", Cluster: "c1", Profile: "live"},
+			wantOk: true,
+		},
+		{
+			name:   "3positive - no current-context",
+			data:   "apiVersion: v1\ncontexts:\n- name: a\ncurrent-context: \"\"\n",
+			want:   PromptInfo{},
+			wantOk: true,
+		},
+		{
+			name:   "4negative - current-context not in the list",
+			data:   "contexts:\n- name: a\ncurrent-context: b\n",
+			wantOk: false,
+		},
+		{
+			name:   "5negative - json",
+			data:   `{"current-context": "a"}`,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := scanCurrentContext([]byte(tt.data))
+			if ok != tt.wantOk {
+				t.Errorf("scanCurrentContext() ok = %v, want %v", ok, tt.wantOk)
+				return
+			}
+			if ok && *got != tt.want {
+				t.Errorf("scanCurrentContext() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// setupTempFiles copies the test kube config and the AWS credentials file into a temporary
+// directory and points the environment variables to them. The kube config gets the given
+// number of additional contexts.
+func setupTempFiles(t testing.TB, contexts int) (string, func()) {
+	dir, err := ioutil.TempDir("", "eksdefault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(testFileContent)
+	var b strings.Builder
+	for i := 0; i < contexts; i++ {
+		fmt.Fprintf(&b, "- context:\n    cluster: c%d\n    user: u%d\n  name: ctx%d\n  aws-profile: dev\n", i, i, i)
+	}
+	content = strings.Replace(content, "contexts:\n", "contexts:\n"+b.String(), 1)
+	path := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := ioutil.ReadFile("testdata/.aws/credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "credentials"), creds, 0644); err != nil {
+		t.Fatal(err)
+	}
+	isolateState(t)
+	t.Setenv("KUBECONFIG", path)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	return path, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReadPromptInfo(t *testing.T) {
+	path, teardown := setupTempFiles(t, 0)
+	defer teardown()
+
+	got, err := ReadPromptInfo()
+	if err != nil {
+		t.Fatalf("ReadPromptInfo() error = %v", err)
+	}
+	if got.Context != "cntxB" || got.Profile != "live" {
+		t.Errorf("ReadPromptInfo() = %+v, want context cntxB with profile live", got)
+	}
+	// the cache is used as long as the file does not change
+	if err := ioutil.WriteFile(filepath.Join(StateDir(), promptCacheFile), []byte(
+		fmt.Sprintf(`{"kubeconfig":%s,"credentials":%s,"info":{"context":"cached"}}`,
+			stampJSON(stampOf(OSFileSystem{}, path)), stampJSON(stampOf(OSFileSystem{}, CredentialsPath()))),
+	), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ReadPromptInfo(); err != nil || got.Context != "cached" {
+		t.Errorf("ReadPromptInfo() = %+v, %v, want the cached context", got, err)
+	}
+	// a changed kube config invalidates the cache
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ReadPromptInfo(); err != nil || got.Context != "cntxB" {
+		t.Errorf("ReadPromptInfo() = %+v, %v, want context cntxB", got, err)
+	}
+}
+
+func stampJSON(s fileStamp) string {
+	return fmt.Sprintf(`{"path":%q,"size":%d,"mod-time":%d}`, s.Path, s.Size, s.ModTime)
+}
+
+func BenchmarkReadPromptInfo(b *testing.B) {
+	_, teardown := setupTempFiles(b, 1000)
+	defer teardown()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadPromptInfo(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
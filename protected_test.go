@@ -0,0 +1,110 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/peterbueschel/awsdefault"
+)
+
+func TestKubeConfig_SetProtected(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetProtected("cntxC", true); err != nil {
+		t.Fatalf("SetProtected() error = %v", err)
+	}
+	if err := k.SetProtected("unknown", true); err == nil {
+		t.Errorf("SetProtected() of an unknown context, want error")
+	}
+
+	k, _ = GetConfigFile()
+	if err := k.SetContextTo("cntxC"); err != NotConfirmed {
+		t.Errorf("SetContextTo() without confirmation error = %v, want %v", err, NotConfirmed)
+	}
+	denied := errors.New("denied")
+	var asked string
+	k.ConfirmProtected = func(ctx *KubeContext) error {
+		asked = ctx.Name
+		return denied
+	}
+	if err := k.SetContextTo("cntxC"); err != denied {
+		t.Errorf("SetContextTo() with denied confirmation error = %v, want %v", err, denied)
+	}
+	if asked != "cntxC" {
+		t.Errorf("ConfirmProtected() asked for %q, want cntxC", asked)
+	}
+	// nothing changed so far
+	k, _ = GetConfigFile()
+	if k.CurrentContext != "cntxB" {
+		t.Errorf("current-context = %s, want cntxB", k.CurrentContext)
+	}
+	if awsfile, err := awsdefault.GetCredentialsFile(); err != nil || activeProfile(awsfile) == "dev" {
+		t.Errorf("default profile changed to dev, want unchanged")
+	}
+	k.ConfirmProtected = func(ctx *KubeContext) error { return nil }
+	if err := k.SetContextTo("cntxC"); err != nil {
+		t.Errorf("SetContextTo() with confirmation error = %v", err)
+	}
+	// unprotected contexts do not ask
+	k.ConfirmProtected = nil
+	if err := k.SetContextTo("cntxA"); err != nil {
+		t.Errorf("SetContextTo() of an unprotected context error = %v", err)
+	}
+	if err := k.SetProtected("cntxC", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetContextTo("cntxC"); err != nil {
+		t.Errorf("SetContextTo() after unprotecting error = %v", err)
+	}
+}
+
+func TestKubeConfig_AccountOf(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  KubeContext
+		want string
+	}{
+		{
+			name: "0positive - cluster ARN",
+			ctx:  KubeContext{Name: "prod", Context: &Context{Cluster: "arn:aws:eks:eu-central-1:123456789012:cluster/prod"}},
+			want: "123456789012",
+		},
+		{
+			name: "1positive - context ARN in another partition",
+			ctx:  KubeContext{Name: "arn:aws-cn:eks:cn-north-1:210987654321:cluster/x", Context: &Context{Cluster: "x"}},
+			want: "210987654321",
+		},
+		{
+			name: "2negative - no ARN",
+			ctx:  KubeContext{Name: "minikube", Context: &Context{Cluster: "minikube"}},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &KubeConfig{}
+			if got := k.AccountOf(&tt.ctx); got != tt.want {
+				t.Errorf("AccountOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	// ClusterProbe checks, whether the cluster used by a context still exists. It returns the
+	// reason, if the cluster is gone, and an error, if this cannot be decided.
+	ClusterProbe interface {
+		Probe(ctx KubeContext, cluster *KubeCluster) (reason string, gone bool, err error)
+	}
+
+	// EKSProbe asks the EKS API for clusters referenced by an EKS ARN. The request is signed
+	// with the aws-profile of the context. All other contexts are passed to the Fallback.
+	EKSProbe struct {
+		// Endpoint replaces https://eks.<region>.amazonaws.com, e.g. for VPC endpoints.
+		Endpoint string
+		Client   *http.Client
+		// Fallback probes contexts without EKS ARN; they are kept, if not set.
+		Fallback ClusterProbe
+		// Options locate the AWS credentials file like for GetConfigFile.
+		Options []Option
+	}
+
+	// ReachabilityProbe connects to the API server of the cluster. A cluster is gone, if
+	// the name of the server does not exist anymore. Other connection errors, like being
+	// offline, leave the cluster undecided.
+	ReachabilityProbe struct {
+		Timeout time.Duration
+	}
+
+	// StaleContext is a context whose cluster is gone or could not be checked.
+	StaleContext struct {
+		Name   string
+		Reason string
+	}
+
+	// PruneResult lists the removed entries.
+	PruneResult struct {
+		Contexts []string
+		Clusters []string
+		Users    []string
+	}
+)
+
+// EKSEndpoint returns the endpoint of the EKS API configured via EKSDEFAULT_EKS_ENDPOINT.
+func EKSEndpoint() string {
+	return os.Getenv("EKSDEFAULT_EKS_ENDPOINT")
+}
+
+// eksCluster returns the region and the name of the EKS cluster used by the context.
+func eksCluster(ctx KubeContext) (string, string, bool) {
+	for _, name := range []string{ctx.Context.Cluster, ctx.Name} {
+		if m := eksARN.FindStringSubmatch(name); m != nil {
+			return m[1], m[3], true
+		}
+	}
+	return "", "", false
+}
+
+// Probe calls DescribeCluster of the EKS API.
+func (p *EKSProbe) Probe(ctx KubeContext, cluster *KubeCluster) (string, bool, error) {
+	region, name, ok := eksCluster(ctx)
+	if !ok {
+		if p.Fallback == nil {
+			return "", false, nil
+		}
+		return p.Fallback.Probe(ctx, cluster)
+	}
+	if len(ctx.AWSprofile) < 1 {
+		return "", false, NoProfilSet
+	}
+	awsfile, err := newKubeConfig(p.Options).credentialsFile()
+	if err != nil {
+		return "", false, err
+	}
+	profile, err := awsfile.GetProfileBy(ctx.AWSprofile)
+	if err != nil {
+		return "", false, err
+	}
+	endpoint := p.Endpoint
+	if len(endpoint) < 1 {
+		endpoint = fmt.Sprintf("https://eks.%s.amazonaws.com", region)
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint+"/clusters/"+url.PathEscape(name), nil)
+	if err != nil {
+		return "", false, err
+	}
+	signV4(req, awsCredentials{
+		AccessKeyID:     profile.AccessKeyID,
+		SecretAccessKey: profile.SecretAccessKey,
+		SessionToken:    profile.SessionToken,
+	}, region, "eks", time.Now())
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		return "", false, nil
+	}
+	apiErr := struct {
+		Message string `json:"message"`
+	}{}
+	_ = json.Unmarshal(body, &apiErr)
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Sprintf("cluster %s not found in %s", name, region), true, nil
+	}
+	return "", false, fmt.Errorf("[EKS] %s: %s", resp.Status, apiErr.Message)
+}
+
+// Probe dials the host of the server URL.
+func (p *ReachabilityProbe) Probe(ctx KubeContext, cluster *KubeCluster) (string, bool, error) {
+	if len(cluster.Cluster.Server) < 1 {
+		return "no server configured", true, nil
+	}
+	u, err := url.Parse(cluster.Cluster.Server)
+	if err != nil {
+		return "", false, err
+	}
+	host := u.Host
+	if len(u.Port()) < 1 {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return fmt.Sprintf("server not found: %v", err), true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return "", false, conn.Close()
+}
+
+// StaleContexts probes the clusters of all contexts in parallel. It returns the contexts
+// whose cluster is gone and the contexts, which could not be checked, with the error as
+// reason. Contexts referencing a cluster missing in the kube config are always stale.
+func (k *KubeConfig) StaleContexts(p ClusterProbe) (stale []StaleContext, failed []StaleContext) {
+	type result struct {
+		reason string
+		gone   bool
+		err    error
+	}
+	results := make([]result, len(k.Contexts))
+	var wg sync.WaitGroup
+	for idx, ctx := range k.Contexts {
+		if k.IsDangling(ctx) {
+			results[idx] = result{reason: "cluster missing in kube config", gone: true}
+			continue
+		}
+		cluster, _, _ := k.GetClusterBy(ctx.Context.Cluster)
+		wg.Add(1)
+		go func(idx int, ctx KubeContext, cluster *KubeCluster) {
+			defer wg.Done()
+			reason, gone, err := p.Probe(ctx, cluster)
+			results[idx] = result{reason, gone, err}
+		}(idx, ctx, cluster)
+	}
+	wg.Wait()
+	for idx, r := range results {
+		name := k.Contexts[idx].Name
+		switch {
+		case r.err != nil:
+			failed = append(failed, StaleContext{Name: name, Reason: r.err.Error()})
+		case r.gone:
+			stale = append(stale, StaleContext{Name: name, Reason: r.reason})
+		}
+	}
+	return stale, failed
+}
+
+// Prune deletes the contexts together with their clusters and users, if no other context
+// uses them anymore. Protected contexts must be confirmed via ConfirmProtected.
+func (k *KubeConfig) Prune(contextNames []string) (*PruneResult, error) {
+	result := &PruneResult{}
+	removed := []KubeContext{}
+	for _, name := range contextNames {
+		ctx, _, err := k.GetContextBy(name)
+		if err != nil {
+			return nil, err
+		}
+		if k.IsProtected(ctx) && !k.DryRun {
+			if k.ConfirmProtected == nil {
+				return nil, fmt.Errorf("[PRUNE] the context '%s' is protected", name)
+			}
+			if err = k.ConfirmProtected(ctx); err != nil {
+				return nil, err
+			}
+		}
+		removed = append(removed, *ctx)
+	}
+	for _, ctx := range removed {
+		_, idx, err := k.GetContextBy(ctx.Name)
+		if err != nil { // listed twice
+			continue
+		}
+		k.Contexts = append(k.Contexts[:idx], k.Contexts[idx+1:]...)
+		if k.CurrentContext == ctx.Name {
+			k.CurrentContext = ""
+		}
+		result.Contexts = append(result.Contexts, ctx.Name)
+	}
+	for _, ctx := range removed {
+		if _, idx, err := k.GetClusterBy(ctx.Context.Cluster); err == nil && len(k.ContextsUsing(ctx.Context.Cluster, "")) < 1 {
+			k.Clusters = append(k.Clusters[:idx], k.Clusters[idx+1:]...)
+			result.Clusters = append(result.Clusters, ctx.Context.Cluster)
+		}
+		if _, idx, err := k.GetUserBy(ctx.Context.User); err == nil && len(k.ContextsUsing("", ctx.Context.User)) < 1 {
+			k.Users = append(k.Users[:idx], k.Users[idx+1:]...)
+			result.Users = append(result.Users, ctx.Context.User)
+		}
+	}
+	if err := k.SaveContexts(); err != nil {
+		return nil, err
+	}
+	for _, ctx := range removed {
+		err := k.audit(AuditRecord{
+			Action:   ActionPrune,
+			Context:  ctx.Name,
+			Cluster:  ctx.Context.Cluster,
+			KubeUser: ctx.Context.User,
+			Old:      ctx.Name,
+			Profile:  ctx.AWSprofile,
+			Account:  k.AccountOf(&ctx),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
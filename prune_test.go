@@ -0,0 +1,204 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type probeFunc func(ctx KubeContext, cluster *KubeCluster) (string, bool, error)
+
+func (f probeFunc) Probe(ctx KubeContext, cluster *KubeCluster) (string, bool, error) {
+	return f(ctx, cluster)
+}
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla of the AWS signature version 4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+	signV4(req, awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}, "us-east-1", "service", now)
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("signV4() Authorization = %s, want %s", got, want)
+	}
+}
+
+func TestEKSProbe(t *testing.T) {
+	_, teardown := setupEKSConfig(t)
+	defer teardown()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/eks/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/clusters/prod":
+			w.Write([]byte(`{"cluster":{"name":"prod","status":"ACTIVE"}}`))
+		case "/clusters/denied":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"not authorized"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No cluster found"}`))
+		}
+	}))
+	defer server.Close()
+	fallback := probeFunc(func(ctx KubeContext, cluster *KubeCluster) (string, bool, error) {
+		return "fallback", true, nil
+	})
+	p := &EKSProbe{Endpoint: server.URL, Fallback: fallback}
+	arn := "arn:aws:eks:eu-west-1:123456789012:cluster/"
+	tests := []struct {
+		name       string
+		ctx        KubeContext
+		wantReason string
+		wantGone   bool
+		wantErr    bool
+	}{
+		{
+			name: "0positive - existing cluster",
+			ctx:  KubeContext{Name: "prod", AWSprofile: "live", Context: &Context{Cluster: arn + "prod"}},
+		},
+		{
+			name:       "1positive - deleted cluster",
+			ctx:        KubeContext{Name: "gone", AWSprofile: "live", Context: &Context{Cluster: arn + "gone"}},
+			wantReason: "cluster gone not found in eu-west-1",
+			wantGone:   true,
+		},
+		{
+			name:       "2positive - ARN as context name",
+			ctx:        KubeContext{Name: arn + "gone", AWSprofile: "live", Context: &Context{Cluster: "gone"}},
+			wantReason: "cluster gone not found in eu-west-1",
+			wantGone:   true,
+		},
+		{
+			name:       "3positive - fallback",
+			ctx:        KubeContext{Name: "kind", Context: &Context{Cluster: "kind"}},
+			wantReason: "fallback",
+			wantGone:   true,
+		},
+		{
+			name:    "4negative - access denied",
+			ctx:     KubeContext{Name: "denied", AWSprofile: "live", Context: &Context{Cluster: arn + "denied"}},
+			wantErr: true,
+		},
+		{
+			name:    "5negative - no aws-profile",
+			ctx:     KubeContext{Name: "prod", Context: &Context{Cluster: arn + "prod"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, gone, err := p.Probe(tt.ctx, &KubeCluster{Cluster: &ClusterInfo{}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if reason != tt.wantReason || gone != tt.wantGone {
+				t.Errorf("Probe() = %q, %v, want %q, %v", reason, gone, tt.wantReason, tt.wantGone)
+			}
+		})
+	}
+}
+
+func TestReachabilityProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	p := &ReachabilityProbe{Timeout: time.Second}
+	for server, wantGone := range map[string]bool{
+		"https://" + l.Addr().String(): false,
+		"https://cluster.invalid":      true,
+		"":                             true,
+	} {
+		_, gone, err := p.Probe(KubeContext{}, &KubeCluster{Cluster: &ClusterInfo{Server: server}})
+		if err != nil || gone != wantGone {
+			t.Errorf("Probe(%q) = %v, %v, want %v", server, gone, err, wantGone)
+		}
+	}
+	// an unreachable server may only be temporarily unreachable
+	server := "https://" + closed.Addr().String()
+	if _, gone, err := p.Probe(KubeContext{}, &KubeCluster{Cluster: &ClusterInfo{Server: server}}); err == nil || gone {
+		t.Errorf("Probe(%q) = %v, %v, want undecided", server, gone, err)
+	}
+}
+
+func TestKubeConfig_Prune(t *testing.T) {
+	_, teardown := setupTempFiles(t, 0)
+	defer teardown()
+	k, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := errors.New("unknown")
+	stale, failed := k.StaleContexts(probeFunc(func(ctx KubeContext, cluster *KubeCluster) (string, bool, error) {
+		return "", false, unknown
+	}))
+	if len(stale) != 3 || stale[0].Name != "cntxA" || stale[0].Reason != "cluster missing in kube config" {
+		t.Errorf("StaleContexts() stale = %+v, want the dangling contexts cntxA, cntxB and cntxC", stale)
+	}
+	if len(failed) != 1 || failed[0].Name != "minikube" || failed[0].Reason != unknown.Error() {
+		t.Errorf("StaleContexts() failed = %+v, want minikube", failed)
+	}
+
+	if err := k.SetProtected("cntxB", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Prune([]string{"cntxB"}); err == nil {
+		t.Errorf("Prune() of a protected context, want error")
+	}
+	got, err := k.Prune([]string{"cntxA", "cntxC", "minikube", "cntxA"})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	want := &PruneResult{
+		Contexts: []string{"cntxA", "cntxC", "minikube"},
+		Clusters: []string{"minikube"},
+		Users:    []string{"userA", "userC", "minikube"},
+	}
+	if strings.Join(got.Contexts, ",") != strings.Join(want.Contexts, ",") ||
+		strings.Join(got.Clusters, ",") != strings.Join(want.Clusters, ",") ||
+		strings.Join(got.Users, ",") != strings.Join(want.Users, ",") {
+		t.Errorf("Prune() = %+v, want %+v", got, want)
+	}
+	k, err = GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := k.GetContextNames(); strings.Join(names, ",") != "cntxB" || k.CurrentContext != "cntxB" {
+		t.Errorf("Prune() left %v with current-context %s, want only cntxB", names, k.CurrentContext)
+	}
+	if names := k.UserNames(); strings.Join(names, ",") != "userB" {
+		t.Errorf("Prune() left the users %v, want userB", names)
+	}
+}
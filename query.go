@@ -0,0 +1,226 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort orders supported by ContextQuery.SortBy.
+const (
+	SortByName     = "name"
+	SortByProfile  = "profile"
+	SortByCluster  = "cluster"
+	SortByLastUsed = "last-used"
+)
+
+// ContextQuery describes which contexts Query returns and in which order. All string
+// filters are glob patterns supporting '*' and '?'; empty filters match every context.
+type ContextQuery struct {
+	Name      string
+	NameRegex string
+	Profile   string
+	Cluster   string
+	User      string
+	Namespace string
+	// Selector is a label selector like 'env=prod,team in (a,b)'.
+	Selector string
+	// Current returns only the current-context.
+	Current bool
+	// Unbound returns only contexts without an aws-profile.
+	Unbound bool
+	// Dangling returns only contexts referencing a cluster missing in the kube config.
+	Dangling bool
+	// SortBy is one of the SortBy* constants; defaults to SortByName. SortByLastUsed orders
+	// by the switches recorded in the history.
+	SortBy string
+}
+
+// globToRegexp translates a glob pattern into an anchored regular expression.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// MatchGlob reports whether name matches the glob pattern supporting '*' and '?'.
+func MatchGlob(pattern, name string) bool {
+	re, err := globToRegexp(pattern)
+	return err == nil && re.MatchString(name)
+}
+
+// ClusterNames returns a sorted list of all clusters defined inside the kube config.
+func (k *KubeConfig) ClusterNames() (names []string) {
+	for _, c := range k.Clusters {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// UserNames returns a sorted list of all users defined inside the kube config.
+func (k *KubeConfig) UserNames() (names []string) {
+	for _, u := range k.Users {
+		names = append(names, u.Name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// IsDangling reports whether the context references a cluster, which is not defined
+// inside the kube config.
+func (k *KubeConfig) IsDangling(ctx KubeContext) bool {
+	if ctx.Context == nil || len(ctx.Context.Cluster) < 1 {
+		return true
+	}
+	return !inList(ctx.Context.Cluster, k.ClusterNames())
+}
+
+// Query returns all contexts matching the given query sorted by q.SortBy.
+func (k *KubeConfig) Query(q ContextQuery) ([]KubeContext, error) {
+	type filter struct {
+		re    *regexp.Regexp
+		field func(KubeContext) string
+	}
+	filters := []filter{}
+	for _, f := range []struct {
+		pattern string
+		field   func(KubeContext) string
+	}{
+		{q.Name, func(c KubeContext) string { return c.Name }},
+		{q.Profile, func(c KubeContext) string { return c.AWSprofile }},
+		{q.Cluster, func(c KubeContext) string { return c.Context.Cluster }},
+		{q.User, func(c KubeContext) string { return c.Context.User }},
+		{q.Namespace, func(c KubeContext) string { return c.Context.Namespace }},
+	} {
+		if len(f.pattern) < 1 {
+			continue
+		}
+		re, err := globToRegexp(f.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("[QUERY] invalid pattern '%s': %v", f.pattern, err)
+		}
+		filters = append(filters, filter{re, f.field})
+	}
+	var nameRe *regexp.Regexp
+	if len(q.NameRegex) > 0 {
+		re, err := regexp.Compile(q.NameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("[QUERY] invalid regular expression '%s': %v", q.NameRegex, err)
+		}
+		nameRe = re
+	}
+	sel, err := ParseSelector(q.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var lastUsed map[string]time.Time
+	if q.SortBy == SortByLastUsed {
+		if lastUsed, err = k.LastUsed(); err != nil {
+			return nil, err
+		}
+	}
+	less, err := sortFunc(q.SortBy, lastUsed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []KubeContext{}
+	for _, c := range k.Contexts {
+		if c.Context == nil {
+			c.Context = &Context{}
+		}
+		if q.Current && c.Name != k.CurrentContext {
+			continue
+		}
+		if q.Unbound && len(c.AWSprofile) > 0 {
+			continue
+		}
+		if q.Dangling && !k.IsDangling(c) {
+			continue
+		}
+		if !sel.Matches(c.Labels) {
+			continue
+		}
+		if nameRe != nil && !nameRe.MatchString(c.Name) {
+			continue
+		}
+		matched := true
+		for _, f := range filters {
+			if !f.re.MatchString(f.field(c)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, c)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+	return result, nil
+}
+
+// sortFunc returns the less function for the given sort order. lastUsed holds the times of
+// the latest switches used by SortByLastUsed.
+func sortFunc(by string, lastUsed map[string]time.Time) (func(a, b KubeContext) bool, error) {
+	switch by {
+	case "", SortByName:
+		return func(a, b KubeContext) bool { return a.Name < b.Name }, nil
+	case SortByProfile:
+		return func(a, b KubeContext) bool {
+			if a.AWSprofile == b.AWSprofile {
+				return a.Name < b.Name
+			}
+			return a.AWSprofile < b.AWSprofile
+		}, nil
+	case SortByCluster:
+		return func(a, b KubeContext) bool {
+			if a.Context.Cluster == b.Context.Cluster {
+				return a.Name < b.Name
+			}
+			return a.Context.Cluster < b.Context.Cluster
+		}, nil
+	case SortByLastUsed:
+		// most recently used first; contexts never switched to come last
+		return func(a, b KubeContext) bool {
+			ta, tb := lastUsed[a.Name], lastUsed[b.Name]
+			if ta.Equal(tb) {
+				return a.Name < b.Name
+			}
+			return ta.After(tb)
+		}, nil
+	}
+	return nil, fmt.Errorf(
+		"[QUERY] unknown sort order '%s'; use one of %s, %s, %s or %s",
+		by, SortByName, SortByProfile, SortByCluster, SortByLastUsed,
+	)
+}
@@ -0,0 +1,134 @@
+//Copyright 2018 Peter Büschel
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.package awsdefault
+
+package eksdefault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKubeConfig_Query(t *testing.T) {
+	k := &KubeConfig{
+		CurrentContext: "cntxB",
+		Contexts: []KubeContext{
+			KubeContext{Name: "cntxA", AWSprofile: "live",
+				Context: &Context{Cluster: "clstrA", User: "userA", Namespace: "aaaaa"}},
+			KubeContext{Name: "cntxB", AWSprofile: "live",
+				Context: &Context{Cluster: "clstrB", User: "userB", Namespace: "bbbbb"}},
+			KubeContext{Name: "cntxC", AWSprofile: "dev",
+				Context: &Context{Cluster: "clstrC", User: "userC", Namespace: "ccccc"}},
+			KubeContext{Name: "minikube", AWSprofile: ""},
+		},
+	}
+	k.Clusters = append(k.Clusters, KubeCluster{Name: "clstrA"})
+	k.FS, k.StateDir = NewMemFileSystem(nil), memStateDir
+	used := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, e := range []HistoryEntry{
+		{Time: used, To: "cntxB"},
+		{Time: used.Add(time.Hour), From: "cntxB", To: "cntxA"},
+		{Time: used.Add(2 * time.Hour), From: "cntxA", To: "cntxB"},
+		{Time: used.Add(3 * time.Hour), From: "cntxB"},
+	} {
+		if err := k.appendHistory(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		query     ContextQuery
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "0positive - empty query returns all contexts sorted by name",
+			query:     ContextQuery{},
+			wantNames: []string{"cntxA", "cntxB", "cntxC", "minikube"},
+		},
+		{
+			name:      "1positive - filter by profile",
+			query:     ContextQuery{Profile: "live"},
+			wantNames: []string{"cntxA", "cntxB"},
+		},
+		{
+			name:      "2positive - filter by name glob",
+			query:     ContextQuery{Name: "cntx?"},
+			wantNames: []string{"cntxA", "cntxB", "cntxC"},
+		},
+		{
+			name:      "3positive - filter by name regex",
+			query:     ContextQuery{NameRegex: "^(mini|cntxC)"},
+			wantNames: []string{"cntxC", "minikube"},
+		},
+		{
+			name:      "4positive - combined filters",
+			query:     ContextQuery{Cluster: "clstr*", Namespace: "b*"},
+			wantNames: []string{"cntxB"},
+		},
+		{
+			name:      "5positive - current context only",
+			query:     ContextQuery{Current: true},
+			wantNames: []string{"cntxB"},
+		},
+		{
+			name:      "6positive - unbound contexts",
+			query:     ContextQuery{Unbound: true},
+			wantNames: []string{"minikube"},
+		},
+		{
+			name:      "7positive - dangling contexts",
+			query:     ContextQuery{Dangling: true},
+			wantNames: []string{"cntxB", "cntxC", "minikube"},
+		},
+		{
+			name:      "8positive - sort by profile",
+			query:     ContextQuery{SortBy: SortByProfile},
+			wantNames: []string{"minikube", "cntxC", "cntxA", "cntxB"},
+		},
+		{
+			name:      "9positive - sort by last used",
+			query:     ContextQuery{SortBy: SortByLastUsed},
+			wantNames: []string{"cntxB", "cntxA", "cntxC", "minikube"},
+		},
+		{
+			name:    "10negative - unknown sort order",
+			query:   ContextQuery{SortBy: "xxxx"},
+			wantErr: true,
+		},
+		{
+			name:    "11negative - invalid regular expression",
+			query:   ContextQuery{NameRegex: "("},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k.Query(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("KubeConfig.Query() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.wantNames) {
+				t.Errorf("KubeConfig.Query() = %v, want %v", got, tt.wantNames)
+				return
+			}
+			for idx, ctx := range got {
+				if ctx.Name != tt.wantNames[idx] {
+					t.Errorf("KubeConfig.Query() got name = %v, want %v", ctx.Name, tt.wantNames[idx])
+				}
+			}
+		})
+	}
+}